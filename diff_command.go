@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io/fs"
+	"log"
+	"os"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/diff"
+	"git.axiom/axiom/range-series-config-mapper/internal/mapping"
+	"git.axiom/axiom/range-series-config-mapper/internal/pathmap"
+	"git.axiom/axiom/range-series-config-mapper/internal/read"
+)
+
+const diffCommand = "diff"
+
+// resolveConfigAt returns the config that was active at the given timestamp
+func resolveConfigAt(timestampStr string, precedence mapping.Precedence) string {
+	timestamp, err := mapping.ParseTimestamp(timestampStr)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	config := precedence.Config(timestamp)
+	if config == "" {
+		log.Fatalf("Error: No config found at %v", timestamp)
+	}
+
+	log.Printf("Config active at %v: %v\n", timestamp, config)
+	return config
+}
+
+// configFS returns the filesystem of a config within the site
+func configFS(site read.Site, config string) fs.FS {
+	configDir, err := site.Rel(config)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	fsys, err := fs.Sub(site.FS, configDir)
+	if err != nil {
+		log.Fatalf("Error opening config %v: %v", config, err)
+	}
+
+	return fsys
+}
+
+func runDiffCommand(args []string) {
+	flags := flag.NewFlagSet(diffCommand, flag.ExitOnError)
+	siteDir := flags.String("site-dir", "", "Absolute path to HFR site directory. Required when comparing by timestamp.")
+	from := flags.String("from", "", "Timestamp (RFC 3339 or 20060102T150405Z) whose active config is the base of the comparison.")
+	to := flags.String("to", "", "Timestamp (RFC 3339 or 20060102T150405Z) whose active config is compared against the base.")
+	settingsFile := flags.String("settings", "", "Path to a JSON file with per-site settings.")
+	outputFormat := flags.String("output-format", OutputFileTypeText, "The format of the report. Options are 'TEXT' or 'JSON'.")
+	paths := addPathFlags(flags)
+	flags.Parse(args)
+
+	if !(*outputFormat == OutputFileTypeText || *outputFormat == OutputFileTypeJSON) {
+		log.Fatalf("Error: Invalid output-format of '%v'. Supported values are 'TEXT' and 'JSON'.\n", *outputFormat)
+	}
+
+	// Config directories are given either directly as arguments, or as timestamps for a site
+	var fromDir, toDir string
+	var fromFS, toFS fs.FS
+	var rewriter pathmap.Rewriter
+	if flags.NArg() > 0 {
+		if flags.NArg() != 2 || *from != "" || *to != "" {
+			log.Fatalln("Error: Specify either two config directories or --site-dir with --from and --to.")
+		}
+		if *siteDir != "" {
+			rewriter = paths.rewriter(openSite(*siteDir))
+		} else if *paths.relativePaths {
+			log.Fatalln("Error: --relative-paths requires --site-dir.")
+		} else {
+			rewriter = pathmap.New(paths.rules(), "")
+		}
+		fromDir, toDir = rewriter.Input(flags.Arg(0)), rewriter.Input(flags.Arg(1))
+		fromFS, toFS = os.DirFS(fromDir), os.DirFS(toDir)
+	} else {
+		if *siteDir == "" || *from == "" || *to == "" {
+			log.Fatalln("Error: --site-dir, --from and --to must be specified when no config directories are given.")
+		}
+		site := openSite(*siteDir)
+		rewriter = paths.rewriter(site)
+		precedence := loadPrecedence(site, loadSettings(*settingsFile))
+		fromDir = resolveConfigAt(*from, precedence)
+		toDir = resolveConfigAt(*to, precedence)
+		fromFS, toFS = configFS(site, fromDir), configFS(site, toDir)
+	}
+
+	result, err := diff.CompareFS(fromFS, toFS, rewriter.Output(fromDir), rewriter.Output(toDir))
+	if err != nil {
+		log.Fatalf("Error comparing config directories: %v", err)
+	}
+
+	if *outputFormat == OutputFileTypeJSON {
+		jsonData, err := json.MarshalIndent(result, "", "  ")
+		if err != nil {
+			log.Fatalf("Error marshalling diff to JSON: %v", err)
+		}
+		os.Stdout.Write(append(jsonData, '\n'))
+		return
+	}
+
+	if err := result.WriteText(os.Stdout); err != nil {
+		log.Fatalf("Error writing diff: %v", err)
+	}
+}
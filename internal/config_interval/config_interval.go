@@ -6,6 +6,8 @@ type ConfigInterval struct {
 	Start  time.Time
 	End    time.Time
 	Config string
+	// Whether the config lasts until now, e.g. the latest auto config. End is then the time the interval was built.
+	Open bool
 }
 
 func (timeInt ConfigInterval) ContainsTime(timestamp time.Time) bool {
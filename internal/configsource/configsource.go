@@ -0,0 +1,149 @@
+package configsource
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/config_interval"
+	"git.axiom/axiom/range-series-config-mapper/internal/mapping"
+	"git.axiom/axiom/range-series-config-mapper/internal/read"
+)
+
+const configFileNamePattern = `\d{4}\d{2}\d{2}T\d{2}\d{2}\d{2}Z(-(\d{4}\d{2}\d{2}T\d{2}\d{2}\d{2}Z|present))?$`
+
+// ConfigSource provides the config intervals of a site
+type ConfigSource interface {
+	Intervals() ([]config_interval.ConfigInterval, error)
+}
+
+// Checker is implemented by config sources that can report problems with their configs, such as config directories
+// that are ignored
+type Checker interface {
+	Check() ([]string, error)
+}
+
+// Directory reads the config intervals from the names of the config directories within a site directory, e.g.
+// `Config_Auto`. The directory is walked once, on the first call to Intervals or Check, so a new Directory is needed
+// to pick up changes.
+type Directory struct {
+	Site read.Site
+	Dir  string
+	// Naming scheme of the config directories
+	Scheme string
+	// How many levels below Dir config directories are looked for, only its immediate children if zero
+	Depth int
+
+	matches *read.DirMatches
+}
+
+func (d *Directory) find() (read.DirMatches, error) {
+	if d.matches != nil {
+		return *d.matches, nil
+	}
+
+	depth := d.Depth
+	if depth <= 0 {
+		depth = 1
+	}
+
+	res, err := read.FindMatchingDirs(d.Site.FS, d.Dir, configFileNamePattern, depth)
+	if err != nil {
+		return read.DirMatches{}, fmt.Errorf("error reading %s files: %v", d.Dir, err)
+	}
+
+	d.matches = &res
+	return res, nil
+}
+
+// Intervals builds the intervals of the config directories according to their naming scheme
+func (d *Directory) Intervals() ([]config_interval.ConfigInterval, error) {
+	log.Printf("Checking following path for configs: %v\n", d.Site.Path(d.Dir))
+
+	matches, err := d.find()
+	if err != nil {
+		return nil, err
+	}
+
+	return d.build(matches)
+}
+
+func (d *Directory) build(matches read.DirMatches) ([]config_interval.ConfigInterval, error) {
+	configPaths := make([]string, len(matches.Dirs))
+	for i, configPath := range matches.Dirs {
+		configPaths[i] = d.Site.Path(configPath)
+	}
+
+	return mapping.BuildConfigIntervals(d.Scheme, configPaths)
+}
+
+// Check reports the config directories that are ignored for being nested in another config or too deep, and the
+// configs sharing a start time
+func (d *Directory) Check() ([]string, error) {
+	var res []string
+
+	matches, err := d.find()
+	if err != nil {
+		return nil, err
+	}
+	for _, nested := range matches.Nested {
+		res = append(res, fmt.Sprintf("ignoring %v, which is nested inside another config", d.Site.Path(nested)))
+	}
+	for _, tooDeep := range matches.TooDeep {
+		res = append(res, fmt.Sprintf("ignoring %v, which lies deeper than %d level(s) below %s", d.Site.Path(tooDeep), max(d.Depth, 1), d.Dir))
+	}
+
+	timeIntervals, err := d.build(matches)
+	if err != nil {
+		return nil, err
+	}
+	for _, configs := range mapping.DuplicateStarts(timeIntervals) {
+		msg := fmt.Sprintf("configs %v share the same start time", strings.Join(configs, ", "))
+		if d.Scheme == mapping.SchemeAuto {
+			msg += fmt.Sprintf(", only %v is used", configs[len(configs)-1])
+		}
+		res = append(res, msg)
+	}
+
+	return res, nil
+}
+
+// Composite combines the intervals of several sources, sorted by start time
+type Composite []ConfigSource
+
+// Check reports the problems of all sources that can be checked
+func (c Composite) Check() ([]string, error) {
+	var res []string
+
+	for _, source := range c {
+		if checker, ok := source.(Checker); ok {
+			problems, err := checker.Check()
+			if err != nil {
+				return nil, err
+			}
+			res = append(res, problems...)
+		}
+	}
+
+	return res, nil
+}
+
+// Intervals returns the intervals of all sources, failing if any of them fails
+func (c Composite) Intervals() ([]config_interval.ConfigInterval, error) {
+	var res []config_interval.ConfigInterval
+
+	for _, source := range c {
+		timeIntervals, err := source.Intervals()
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, timeIntervals...)
+	}
+
+	slices.SortStableFunc(res, func(a, b config_interval.ConfigInterval) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	return res, nil
+}
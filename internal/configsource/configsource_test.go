@@ -0,0 +1,227 @@
+package configsource
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/config_interval"
+	"git.axiom/axiom/range-series-config-mapper/internal/mapping"
+	"git.axiom/axiom/range-series-config-mapper/internal/read"
+)
+
+var testSite = read.NewSite(fstest.MapFS{
+	"Config_Auto/20230501T000000Z/Header.txt":                      {Data: []byte("MGS1 ! Site Code\n")},
+	"Config_Auto/20230520T120000Z/Header.txt":                      {Data: []byte("MGS1 ! Site Code\n")},
+	"Config_Operator/20230510T000000Z-20230515T000000Z/Header.txt": {Data: []byte("MGS1 ! Site Code\n")},
+	"Configs/spring_deployment/Header.txt":                         {Data: []byte("MGS1 ! Site Code\n")},
+}, "/archive/MGS1")
+
+func configs(timeIntervals []config_interval.ConfigInterval) []string {
+	var res []string
+	for _, timeInterval := range timeIntervals {
+		res = append(res, timeInterval.Config)
+	}
+	return res
+}
+
+func TestDirectory(t *testing.T) {
+	// Arrange
+	source := &Directory{Site: testSite, Dir: "Config_Auto", Scheme: mapping.SchemeAuto}
+
+	// Execute test
+	got, err := source.Intervals()
+	if err != nil {
+		t.Fatalf("Intervals() error = %v", err)
+	}
+
+	// Assert results
+	want := []string{"/archive/MGS1/Config_Auto/20230501T000000Z", "/archive/MGS1/Config_Auto/20230520T120000Z"}
+	if !reflect.DeepEqual(configs(got), want) {
+		t.Errorf("Intervals() = %v, want %v", configs(got), want)
+	}
+	if !got[0].End.Equal(got[1].Start) {
+		t.Errorf("Intervals() first interval ends at %v, want %v", got[0].End, got[1].Start)
+	}
+}
+
+func TestDirectoryWalksOnce(t *testing.T) {
+	// Arrange
+	fsys := fstest.MapFS{
+		"Config_Auto/20230501T000000Z/Header.txt": {},
+	}
+	source := &Directory{Site: read.NewSite(fsys, "/archive/MGS1"), Dir: "Config_Auto", Scheme: mapping.SchemeAuto}
+
+	// Execute test
+	if _, err := source.Intervals(); err != nil {
+		t.Fatalf("Intervals() error = %v", err)
+	}
+	// A config added after the walk is not picked up by the same source
+	fsys["Config_Auto/20230520T120000Z/Header.txt"] = &fstest.MapFile{}
+	problems, err := source.Check()
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	got, err := source.Intervals()
+	if err != nil {
+		t.Fatalf("Intervals() error = %v", err)
+	}
+
+	// Assert results
+	if want := []string{"/archive/MGS1/Config_Auto/20230501T000000Z"}; !reflect.DeepEqual(configs(got), want) || len(problems) != 0 {
+		t.Errorf("Intervals() = %v and Check() = %v, want %v and no problems", configs(got), problems, want)
+	}
+}
+
+func TestDirectoryCheck(t *testing.T) {
+	// Arrange
+	site := read.NewSite(fstest.MapFS{
+		"Config_Auto/20230501T000000Z/Header.txt":                  {},
+		"Config_Auto/20230520T120000Z/20230101T000000Z/Header.txt": {},
+		"Config_Auto/2023/20230501T000000Z/Header.txt":             {},
+	}, "/archive/MGS1")
+
+	// Define test cases
+	tests := []struct {
+		name string
+		dir  *Directory
+		want []string
+	}{
+		{
+			name: "Immediate children",
+			dir:  &Directory{Site: site, Dir: "Config_Auto", Scheme: mapping.SchemeAuto},
+			want: []string{
+				"ignoring /archive/MGS1/Config_Auto/20230520T120000Z/20230101T000000Z, which is nested inside another config",
+				"ignoring /archive/MGS1/Config_Auto/2023/20230501T000000Z, which lies deeper than 1 level(s) below Config_Auto",
+			},
+		},
+		{
+			name: "Duplicate start times",
+			dir:  &Directory{Site: site, Dir: "Config_Auto", Scheme: mapping.SchemeAuto, Depth: 2},
+			want: []string{
+				"ignoring /archive/MGS1/Config_Auto/20230520T120000Z/20230101T000000Z, which is nested inside another config",
+				"configs /archive/MGS1/Config_Auto/2023/20230501T000000Z, /archive/MGS1/Config_Auto/20230501T000000Z share the same start time, only /archive/MGS1/Config_Auto/20230501T000000Z is used",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute test
+			got, err := tt.dir.Check()
+
+			// Assert results
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Check() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManifest(t *testing.T) {
+	// Arrange
+	loadedAt := time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+	originalTimeNow := timeNow
+	timeNow = func() time.Time { return loadedAt }
+	defer func() { timeNow = originalTimeNow }()
+
+	// Define test cases
+	tests := []struct {
+		name     string
+		file     string
+		contents string
+		want     []config_interval.ConfigInterval
+		wantErr  bool
+	}{
+		{
+			name:     "CSV",
+			file:     "manifest.csv",
+			contents: "start,end,config\n20230520T000000Z,,Configs/summer_deployment\n2023-05-01T00:00:00Z, 2023-05-20T00:00:00Z, Configs/spring_deployment\n",
+			want: []config_interval.ConfigInterval{
+				{Start: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2023, 5, 20, 0, 0, 0, 0, time.UTC), Config: "/archive/MGS1/Configs/spring_deployment"},
+				{Start: time.Date(2023, 5, 20, 0, 0, 0, 0, time.UTC), End: loadedAt, Config: "/archive/MGS1/Configs/summer_deployment", Open: true},
+			},
+		},
+		{
+			name:     "JSON",
+			file:     "manifest.json",
+			contents: `[{"config": "/mnt/configs/spring", "start": "20230501T000000Z", "end": "present"}]`,
+			want: []config_interval.ConfigInterval{
+				{Start: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), End: loadedAt, Config: "/mnt/configs/spring", Open: true},
+			},
+		},
+		{
+			name:     "Missing column",
+			file:     "manifest.csv",
+			contents: "config,end\nConfigs/spring_deployment,20230520T000000Z\n",
+			wantErr:  true,
+		},
+		{
+			name:     "Ends before start",
+			file:     "manifest.csv",
+			contents: "config,start,end\nConfigs/spring_deployment,20230520T000000Z,20230501T000000Z\n",
+			wantErr:  true,
+		},
+		{
+			name:     "Unsupported format",
+			file:     "manifest.txt",
+			contents: "config,start,end\n",
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := os.WriteFile(path, []byte(tt.contents), 0644); err != nil {
+				t.Fatalf("Failed to write manifest: %v", err)
+			}
+
+			// Execute test
+			got, err := Manifest{Site: testSite, Path: path}.Intervals()
+
+			// Assert results
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Intervals() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Intervals() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestComposite(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "manifest.csv")
+	if err := os.WriteFile(path, []byte("config,start,end\nConfigs/spring_deployment,20230512T000000Z,20230513T000000Z\n"), 0644); err != nil {
+		t.Fatalf("Failed to write manifest: %v", err)
+	}
+	source := Composite{
+		&Directory{Site: testSite, Dir: "Config_Operator", Scheme: mapping.SchemeOperator},
+		Manifest{Site: testSite, Path: path},
+		&Directory{Site: testSite, Dir: "Config_Missing", Scheme: mapping.SchemeOperator},
+	}
+
+	// Execute test
+	_, err := source.Intervals()
+	if err == nil {
+		t.Errorf("Intervals() error = nil, want error for missing directory")
+	}
+	got, err := source[:2].Intervals()
+
+	// Assert results
+	if err != nil {
+		t.Fatalf("Intervals() error = %v", err)
+	}
+	want := []string{"/archive/MGS1/Config_Operator/20230510T000000Z-20230515T000000Z", "/archive/MGS1/Configs/spring_deployment"}
+	if !reflect.DeepEqual(configs(got), want) {
+		t.Errorf("Intervals() = %v, want %v", configs(got), want)
+	}
+}
@@ -0,0 +1,157 @@
+package configsource
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/config_interval"
+	"git.axiom/axiom/range-series-config-mapper/internal/mapping"
+	"git.axiom/axiom/range-series-config-mapper/internal/read"
+)
+
+// Columns of a CSV manifest, given in its header row
+const (
+	manifestConfigColumn = "config"
+	manifestStartColumn  = "start"
+	manifestEndColumn    = "end"
+)
+
+// An end of `present`, or no end at all, leaves the interval open until the time of loading
+const manifestPresentToken = "present"
+
+var timeNow = func() time.Time {
+	return time.Now()
+}
+
+// ManifestEntry is a config and its time interval as exported from a metadata database
+type ManifestEntry struct {
+	Config string `json:"config"`
+	Start  string `json:"start"`
+	End    string `json:"end"`
+}
+
+// Manifest reads the config intervals from a CSV or JSON manifest, for sites whose config directories are not named by
+// their timestamps. Relative config paths are resolved against the site directory.
+type Manifest struct {
+	Site read.Site
+	Path string
+}
+
+// Intervals reads the manifest, choosing the format by the file extension
+func (m Manifest) Intervals() ([]config_interval.ConfigInterval, error) {
+	file, err := os.Open(m.Path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var entries []ManifestEntry
+	switch strings.ToLower(filepath.Ext(m.Path)) {
+	case ".csv":
+		entries, err = readCsvManifest(file)
+	case ".json":
+		err = json.NewDecoder(file).Decode(&entries)
+	default:
+		return nil, fmt.Errorf("unsupported manifest %s, expected a .csv or .json file", m.Path)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading manifest %s: %v", m.Path, err)
+	}
+
+	res := []config_interval.ConfigInterval{}
+	for i, entry := range entries {
+		timeInterval, err := m.buildInterval(entry)
+		if err != nil {
+			return nil, fmt.Errorf("error in manifest %s, entry %d: %v", m.Path, i+1, err)
+		}
+		res = append(res, timeInterval)
+	}
+
+	slices.SortStableFunc(res, func(a, b config_interval.ConfigInterval) int {
+		return a.Start.Compare(b.Start)
+	})
+
+	return res, nil
+}
+
+func (m Manifest) buildInterval(entry ManifestEntry) (config_interval.ConfigInterval, error) {
+	if entry.Config == "" {
+		return config_interval.ConfigInterval{}, fmt.Errorf("missing config")
+	}
+
+	start, err := mapping.ParseTimestamp(entry.Start)
+	if err != nil {
+		return config_interval.ConfigInterval{}, err
+	}
+
+	end, open := timeNow().UTC(), true
+	if entry.End != "" && entry.End != manifestPresentToken {
+		open = false
+		end, err = mapping.ParseTimestamp(entry.End)
+		if err != nil {
+			return config_interval.ConfigInterval{}, err
+		}
+	}
+	if !start.Before(end) {
+		return config_interval.ConfigInterval{}, fmt.Errorf("config %s ends before it starts", entry.Config)
+	}
+
+	config := entry.Config
+	if !filepath.IsAbs(config) {
+		config = m.Site.Path(filepath.ToSlash(config))
+	}
+
+	return config_interval.ConfigInterval{Start: start, End: end, Config: config, Open: open}, nil
+}
+
+// readCsvManifest reads a CSV manifest whose header row names the config, start and end columns, in any order
+func readCsvManifest(r io.Reader) ([]ManifestEntry, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range []string{manifestConfigColumn, manifestStartColumn} {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("missing '%s' column", name)
+		}
+	}
+
+	column := func(record []string, name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var res []ManifestEntry
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return res, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		res = append(res, ManifestEntry{
+			Config: column(record, manifestConfigColumn),
+			Start:  column(record, manifestStartColumn),
+			End:    column(record, manifestEndColumn),
+		})
+	}
+}
@@ -0,0 +1,292 @@
+package diff
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+)
+
+const (
+	StatusAdded   = "added"
+	StatusRemoved = "removed"
+	StatusChanged = "changed"
+)
+
+const (
+	LineAdded   = "+"
+	LineRemoved = "-"
+)
+
+// SeaSonde text files whose contents are compared line-by-line (or key-by-key) rather than just flagged as changed
+var knownTextFiles = []string{
+	"Header.txt",
+	"AnalysisOptions.txt",
+}
+
+// Separates the value(s) of a line in a known text file from its descriptive label, e.g. `MGS1 ! Site Code`
+const keyDelimiter = "!"
+
+// KeyChange is a key of a known text file that was added, removed or whose value changed. Values that don't exist on
+// either side are left empty, so the status tells an added or removed key from a value changed to or from empty.
+type KeyChange struct {
+	Key      string `json:"key"`
+	Status   string `json:"status"`
+	OldValue string `json:"old_value,omitempty"`
+	NewValue string `json:"new_value,omitempty"`
+}
+
+type LineChange struct {
+	Op   string `json:"op"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+type FileDiff struct {
+	Path   string       `json:"path"`
+	Status string       `json:"status"`
+	Keys   []KeyChange  `json:"keys,omitempty"`
+	Lines  []LineChange `json:"lines,omitempty"`
+}
+
+type Result struct {
+	From  string     `json:"from"`
+	To    string     `json:"to"`
+	Files []FileDiff `json:"files"`
+}
+
+func listFiles(fsys fs.FS) (map[string]bool, error) {
+	files := make(map[string]bool)
+
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files[name] = true
+		}
+		return nil
+	})
+
+	return files, err
+}
+
+// CompareFS reports the files added, removed and changed between two configs given as filesystems. The from and to
+// names identify the configs in the result.
+func CompareFS(fromFS, toFS fs.FS, from, to string) (Result, error) {
+	res := Result{From: from, To: to, Files: []FileDiff{}}
+
+	fromFiles, err := listFiles(fromFS)
+	if err != nil {
+		return Result{}, err
+	}
+	toFiles, err := listFiles(toFS)
+	if err != nil {
+		return Result{}, err
+	}
+
+	// Collect the union of relative paths so the report is ordered consistently
+	var names []string
+	for name := range fromFiles {
+		names = append(names, name)
+	}
+	for name := range toFiles {
+		if !fromFiles[name] {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	for _, name := range names {
+		if !fromFiles[name] {
+			res.Files = append(res.Files, FileDiff{Path: name, Status: StatusAdded})
+			continue
+		}
+		if !toFiles[name] {
+			res.Files = append(res.Files, FileDiff{Path: name, Status: StatusRemoved})
+			continue
+		}
+
+		fileDiff, changed, err := compareFiles(name, fromFS, toFS)
+		if err != nil {
+			return Result{}, err
+		}
+		if changed {
+			res.Files = append(res.Files, fileDiff)
+		}
+	}
+
+	return res, nil
+}
+
+func compareFiles(name string, fromFS, toFS fs.FS) (FileDiff, bool, error) {
+	fromData, err := fs.ReadFile(fromFS, name)
+	if err != nil {
+		return FileDiff{}, false, err
+	}
+	toData, err := fs.ReadFile(toFS, name)
+	if err != nil {
+		return FileDiff{}, false, err
+	}
+
+	if bytes.Equal(fromData, toData) {
+		return FileDiff{}, false, nil
+	}
+
+	fileDiff := FileDiff{Path: name, Status: StatusChanged}
+	if !slices.Contains(knownTextFiles, path.Base(name)) {
+		return fileDiff, true, nil
+	}
+
+	fromLines := splitLines(string(fromData))
+	toLines := splitLines(string(toData))
+
+	// Prefer key-level differences, falling back to lines when either file isn't consistently keyed
+	fromKeys, fromKeyed := parseKeyedLines(fromLines)
+	toKeys, toKeyed := parseKeyedLines(toLines)
+	if fromKeyed && toKeyed {
+		fileDiff.Keys = diffKeys(fromKeys, toKeys)
+	} else {
+		fileDiff.Lines = diffLines(fromLines, toLines)
+	}
+
+	return fileDiff, true, nil
+}
+
+func splitLines(text string) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.Split(strings.TrimRight(text, "\n"), "\n")
+}
+
+type keyedLine struct {
+	key   string
+	value string
+}
+
+// parseKeyedLines splits every non-blank line into a value and its label. It returns false if any line has no
+// label or a label appears more than once, since keys could then not be matched between files.
+func parseKeyedLines(lines []string) ([]keyedLine, bool) {
+	var res []keyedLine
+	seen := make(map[string]bool)
+
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+
+		value, key, found := strings.Cut(line, keyDelimiter)
+		key = strings.TrimSpace(key)
+		if !found || key == "" || seen[key] {
+			return nil, false
+		}
+		seen[key] = true
+
+		res = append(res, keyedLine{key: key, value: strings.TrimSpace(value)})
+	}
+
+	return res, true
+}
+
+func diffKeys(fromKeys, toKeys []keyedLine) []KeyChange {
+	var res []KeyChange
+
+	toValues := make(map[string]string)
+	for _, line := range toKeys {
+		toValues[line.key] = line.value
+	}
+	fromValues := make(map[string]string)
+	for _, line := range fromKeys {
+		fromValues[line.key] = line.value
+	}
+
+	// Changed and removed keys, in the order of the old file
+	for _, line := range fromKeys {
+		newValue, ok := toValues[line.key]
+		if !ok {
+			res = append(res, KeyChange{Key: line.key, Status: StatusRemoved, OldValue: line.value})
+		} else if newValue != line.value {
+			res = append(res, KeyChange{Key: line.key, Status: StatusChanged, OldValue: line.value, NewValue: newValue})
+		}
+	}
+
+	// Added keys, in the order of the new file
+	for _, line := range toKeys {
+		if _, ok := fromValues[line.key]; !ok {
+			res = append(res, KeyChange{Key: line.key, Status: StatusAdded, NewValue: line.value})
+		}
+	}
+
+	return res
+}
+
+// diffLines computes a minimal line diff using the longest common subsequence of the two files
+func diffLines(fromLines, toLines []string) []LineChange {
+	n, m := len(fromLines), len(toLines)
+
+	// lcs[i][j] holds the LCS length of fromLines[i:] and toLines[j:]
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if fromLines[i] == toLines[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var res []LineChange
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && fromLines[i] == toLines[j]:
+			i++
+			j++
+		case i < n && (j == m || lcs[i+1][j] >= lcs[i][j+1]):
+			res = append(res, LineChange{Op: LineRemoved, Line: i + 1, Text: fromLines[i]})
+			i++
+		default:
+			res = append(res, LineChange{Op: LineAdded, Line: j + 1, Text: toLines[j]})
+			j++
+		}
+	}
+
+	return res
+}
+
+// WriteText renders the result as a human-readable report
+func (r Result) WriteText(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "Comparing %s -> %s\n", r.From, r.To)
+	if len(r.Files) == 0 {
+		b.WriteString("No differences found.\n")
+	}
+
+	for _, file := range r.Files {
+		fmt.Fprintf(&b, "%-8s %s\n", file.Status, file.Path)
+
+		for _, key := range file.Keys {
+			switch key.Status {
+			case StatusAdded:
+				fmt.Fprintf(&b, "    %s: added %q\n", key.Key, key.NewValue)
+			case StatusRemoved:
+				fmt.Fprintf(&b, "    %s: removed %q\n", key.Key, key.OldValue)
+			default:
+				fmt.Fprintf(&b, "    %s: %q -> %q\n", key.Key, key.OldValue, key.NewValue)
+			}
+		}
+		for _, line := range file.Lines {
+			fmt.Fprintf(&b, "    %s %d: %s\n", line.Op, line.Line, line.Text)
+		}
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
@@ -0,0 +1,129 @@
+package diff
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestCompareFS(t *testing.T) {
+	// Arrange
+	fromFS := fstest.MapFS{
+		"Header.txt":          {Data: []byte("MGS1 ! Site Code\n34.4 -119.8 ! Lat Lon\n5 ! Antenna Bearing\n1 ! Channel\n")},
+		"AnalysisOptions.txt": {Data: []byte("1\n2\n3\n")},
+		"MeasPattern.txt":     {Data: []byte("pattern A")},
+		"Phases.txt":          {Data: []byte("0 0")},
+		"Old.txt":             {Data: []byte("gone")},
+	}
+	toFS := fstest.MapFS{
+		"Header.txt":          {Data: []byte("MGS1 ! Site Code\n34.5 -119.8 ! Lat Lon\n ! Antenna Bearing\n13.5 ! Frequency\n")},
+		"AnalysisOptions.txt": {Data: []byte("1\n4\n3\n")},
+		"MeasPattern.txt":     {Data: []byte("pattern B")},
+		"Phases.txt":          {Data: []byte("0 0")},
+		"nested/New.txt":      {Data: []byte("new")},
+	}
+
+	expected := []FileDiff{
+		{
+			Path:   "AnalysisOptions.txt",
+			Status: StatusChanged,
+			Lines: []LineChange{
+				{Op: LineRemoved, Line: 2, Text: "2"},
+				{Op: LineAdded, Line: 2, Text: "4"},
+			},
+		},
+		{
+			Path:   "Header.txt",
+			Status: StatusChanged,
+			Keys: []KeyChange{
+				{Key: "Lat Lon", Status: StatusChanged, OldValue: "34.4 -119.8", NewValue: "34.5 -119.8"},
+				{Key: "Antenna Bearing", Status: StatusChanged, OldValue: "5"},
+				{Key: "Channel", Status: StatusRemoved, OldValue: "1"},
+				{Key: "Frequency", Status: StatusAdded, NewValue: "13.5"},
+			},
+		},
+		{Path: "MeasPattern.txt", Status: StatusChanged},
+		{Path: "Old.txt", Status: StatusRemoved},
+		{Path: "nested/New.txt", Status: StatusAdded},
+	}
+
+	// Execute test
+	got, err := CompareFS(fromFS, toFS, "from", "to")
+	if err != nil {
+		t.Fatalf("CompareFS() error = %v", err)
+	}
+
+	// Assert results
+	if !reflect.DeepEqual(got.Files, expected) {
+		t.Errorf("CompareFS() = %+v, want %+v", got.Files, expected)
+	}
+}
+
+func TestDiffLines(t *testing.T) {
+	// Define test cases
+	tests := []struct {
+		name string
+		from []string
+		to   []string
+		want []LineChange
+	}{
+		{
+			name: "Identical",
+			from: []string{"a", "b"},
+			to:   []string{"a", "b"},
+			want: nil,
+		},
+		{
+			name: "Line inserted",
+			from: []string{"a", "c"},
+			to:   []string{"a", "b", "c"},
+			want: []LineChange{{Op: LineAdded, Line: 2, Text: "b"}},
+		},
+		{
+			name: "Line removed",
+			from: []string{"a", "b", "c"},
+			to:   []string{"a", "c"},
+			want: []LineChange{{Op: LineRemoved, Line: 2, Text: "b"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffLines(tt.from, tt.to)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("diffLines() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteText(t *testing.T) {
+	// Arrange
+	result := Result{From: "from", To: "to", Files: []FileDiff{{
+		Path:   "Header.txt",
+		Status: StatusChanged,
+		Keys: []KeyChange{
+			{Key: "Antenna Bearing", Status: StatusChanged, OldValue: "5"},
+			{Key: "Channel", Status: StatusRemoved, OldValue: "1"},
+			{Key: "Frequency", Status: StatusAdded, NewValue: "13.5"},
+		},
+	}}}
+	expected := `Comparing from -> to
+changed  Header.txt
+    Antenna Bearing: "5" -> ""
+    Channel: removed "1"
+    Frequency: added "13.5"
+`
+
+	// Execute test
+	var got bytes.Buffer
+	if err := result.WriteText(&got); err != nil {
+		t.Fatalf("WriteText() error = %v", err)
+	}
+
+	// Assert results
+	if got.String() != expected {
+		t.Errorf("WriteText() = %q, want %q", got.String(), expected)
+	}
+}
@@ -0,0 +1,152 @@
+package exclude
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/read"
+)
+
+// Lines of a blocklist starting with this are comments
+const blocklistComment = "#"
+
+// The reason given for blocklist entries without one
+const defaultBlockReason = "blocklisted"
+
+// Exclusion is a file left out of a run, and the rule that excluded it
+type Exclusion struct {
+	File string `json:"file"`
+	Rule string `json:"rule"`
+}
+
+// Rules decide which product files take part in a run. Glob patterns without a slash, e.g. `._*` or `*.partial`, match
+// the name of the file or of any directory above it within the site. Patterns with a slash, e.g.
+// `RangeSeries/2023/quarantine*`, match the file's path relative to the site or any of its parent directories. The zero
+// value includes all files.
+type Rules struct {
+	// If given, only files matching any of these are included
+	Include []string
+	// Files matching any of these are excluded, even if included
+	Exclude []string
+	// Reasons for blocking known-bad files, keyed by their name, path relative to the site or absolute path
+	Blocklist map[string]string
+}
+
+// New validates the patterns of the rules
+func New(include []string, exclude []string, blocklist map[string]string) (Rules, error) {
+	for _, pattern := range append(slices.Clone(include), exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return Rules{}, fmt.Errorf("invalid pattern '%s': %v", pattern, err)
+		}
+	}
+
+	return Rules{Include: include, Exclude: exclude, Blocklist: blocklist}, nil
+}
+
+// IsZero reports whether the rules include all files
+func (r Rules) IsZero() bool {
+	return len(r.Include) == 0 && len(r.Exclude) == 0 && len(r.Blocklist) == 0
+}
+
+// Excluded returns the rule excluding the file, given by its display path within the site, if any
+func (r Rules) Excluded(site read.Site, displayPath string) (string, bool) {
+	rel, err := site.Rel(displayPath)
+	if err != nil {
+		// Files outside the site are matched by their full path
+		rel = strings.TrimPrefix(filepath.ToSlash(displayPath), "/")
+	}
+
+	for _, key := range []string{displayPath, rel, path.Base(rel)} {
+		if reason, ok := r.Blocklist[key]; ok {
+			return fmt.Sprintf("blocklist: %s", reason), true
+		}
+	}
+
+	for _, pattern := range r.Exclude {
+		if matches(pattern, rel) {
+			return fmt.Sprintf("exclude '%s'", pattern), true
+		}
+	}
+
+	if len(r.Include) > 0 && !slices.ContainsFunc(r.Include, func(pattern string) bool { return matches(pattern, rel) }) {
+		return fmt.Sprintf("not included by any of '%s'", strings.Join(r.Include, "', '")), true
+	}
+
+	return "", false
+}
+
+// Filter splits the files into those included and those excluded
+func (r Rules) Filter(site read.Site, displayPaths []string) ([]string, []Exclusion) {
+	if r.IsZero() {
+		return displayPaths, nil
+	}
+
+	var res []string
+	var excluded []Exclusion
+	for _, displayPath := range displayPaths {
+		if rule, ok := r.Excluded(site, displayPath); ok {
+			excluded = append(excluded, Exclusion{File: displayPath, Rule: rule})
+			continue
+		}
+		res = append(res, displayPath)
+	}
+
+	return res, excluded
+}
+
+// matches reports whether the pattern matches any component of the relative path, or any of its leading parts if the
+// pattern has a slash
+func matches(pattern string, rel string) bool {
+	components := strings.Split(rel, "/")
+
+	for i := range components {
+		name := components[i]
+		if strings.Contains(pattern, "/") {
+			name = strings.Join(components[:i+1], "/")
+		}
+		if ok, _ := path.Match(pattern, name); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ReadBlocklist reads a blocklist of known-bad files. Each line gives a file, by name, path relative to the site or
+// absolute path, followed by the reason it is blocked. Blank lines and lines starting with `#` are skipped.
+func ReadBlocklist(blocklistPath string) (map[string]string, error) {
+	file, err := os.Open(blocklistPath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	res := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, blocklistComment) {
+			continue
+		}
+
+		entry, reason := line, ""
+		if i := strings.IndexAny(line, " \t"); i >= 0 {
+			entry, reason = line[:i], strings.TrimSpace(line[i+1:])
+		}
+		if reason == "" {
+			reason = defaultBlockReason
+		}
+		res[entry] = reason
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading blocklist %s: %v", blocklistPath, err)
+	}
+
+	return res, nil
+}
@@ -0,0 +1,86 @@
+package exclude
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/read"
+)
+
+var testSite = read.NewSite(fstest.MapFS{}, "/archive/MGS1")
+
+func TestExcluded(t *testing.T) {
+	// Arrange
+	rules, err := New(
+		[]string{"RangeSeries/2023/*"},
+		[]string{"._*", "*.partial", "quarantine*"},
+		map[string]string{"Rng_mgs1_2023_05_17_073610.rs": "truncated transfer"},
+	)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	// Define test cases
+	tests := []struct {
+		name     string
+		path     string
+		wantRule string
+	}{
+		{"Included", "/archive/MGS1/RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610.rs", ""},
+		{"AppleDouble file", "/archive/MGS1/RangeSeries/2023/05/17/._Rng_mgs1_2023_05_17_070610.rs", "exclude '._*'"},
+		{"Partial transfer", "/archive/MGS1/RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610.rs.partial", "exclude '*.partial'"},
+		{"Quarantined directory", "/archive/MGS1/RangeSeries/2023/quarantine_0517/Rng_mgs1_2023_05_17_070610.rs", "exclude 'quarantine*'"},
+		{"Blocklisted", "/archive/MGS1/RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_073610.rs", "blocklist: truncated transfer"},
+		{"Not included", "/archive/MGS1/RangeSeries/2022/05/17/Rng_mgs1_2022_05_17_070610.rs", "not included by any of 'RangeSeries/2023/*'"},
+		{"Archive member", "/archive/MGS1/RangeSeries/2023/05/17.zip!/._Rng_mgs1_2023_05_17_070610.rs", "exclude '._*'"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute test
+			got, ok := rules.Excluded(testSite, tt.path)
+
+			// Assert results
+			if got != tt.wantRule || ok != (tt.wantRule != "") {
+				t.Errorf("Excluded() = %q, %v, want %q", got, ok, tt.wantRule)
+			}
+		})
+	}
+}
+
+func TestNewInvalidPattern(t *testing.T) {
+	// Execute test
+	_, err := New(nil, []string{"[._*"}, nil)
+
+	// Assert results
+	if err == nil {
+		t.Errorf("New() error = nil, want an error for an invalid pattern")
+	}
+}
+
+func TestReadBlocklist(t *testing.T) {
+	// Arrange
+	path := filepath.Join(t.TempDir(), "blocklist.txt")
+	contents := "# Known-bad files\n\nRng_mgs1_2023_05_17_070610.rs  corrupt header\nRangeSeries/2023/05/18/Rng_mgs1_2023_05_18_000000.rs\n"
+	if err := os.WriteFile(path, []byte(contents), 0644); err != nil {
+		t.Fatalf("Failed to write blocklist: %v", err)
+	}
+
+	// Execute test
+	got, err := ReadBlocklist(path)
+
+	// Assert results
+	if err != nil {
+		t.Fatalf("ReadBlocklist() error = %v", err)
+	}
+	want := map[string]string{
+		"Rng_mgs1_2023_05_17_070610.rs":                        "corrupt header",
+		"RangeSeries/2023/05/18/Rng_mgs1_2023_05_18_000000.rs": "blocklisted",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ReadBlocklist() = %v, want %v", got, want)
+	}
+}
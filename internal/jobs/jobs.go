@@ -0,0 +1,169 @@
+package jobs
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/mapping"
+	"git.axiom/axiom/range-series-config-mapper/internal/product"
+)
+
+const (
+	// One job per config
+	WindowConfig = "config"
+	WindowDay    = "day"
+	WindowMonth  = "month"
+)
+
+// Window is the span of time covered by each job of a config: a whole config, a calendar day or month, or a fixed
+// duration such as `6h`
+type Window struct {
+	name     string
+	duration time.Duration
+}
+
+// Job is a chunk of files sharing a config and a time window, rendered by templates
+type Job struct {
+	// Position of the job in the sorted list of jobs, from 0
+	Index      int    `json:"index"`
+	Name       string `json:"name"`
+	Site       string `json:"site"`
+	Config     string `json:"config"`
+	ConfigName string `json:"config_name"`
+	// Start and end of the window. For config windows, the times of the first and last files.
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+	// Files sorted by time
+	Files []string `json:"files"`
+}
+
+type jobFile struct {
+	path string
+	time time.Time
+}
+
+// ParseWindow parses `config`, `day`, `month` or a duration
+func ParseWindow(str string) (Window, error) {
+	switch str {
+	case WindowConfig, WindowDay, WindowMonth:
+		return Window{name: str}, nil
+	}
+
+	duration, err := time.ParseDuration(str)
+	if err != nil || duration <= 0 {
+		return Window{}, fmt.Errorf("invalid window '%s', expected 'config', 'day', 'month' or a positive duration such as '6h'", str)
+	}
+	return Window{duration: duration}, nil
+}
+
+// bounds returns the start and end of the window containing the timestamp, and the label used in job names
+func (w Window) bounds(timestamp time.Time) (time.Time, time.Time, string) {
+	timestamp = timestamp.UTC()
+
+	switch w.name {
+	case WindowDay:
+		start := time.Date(timestamp.Year(), timestamp.Month(), timestamp.Day(), 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 0, 1), start.Format("20060102")
+	case WindowMonth:
+		start := time.Date(timestamp.Year(), timestamp.Month(), 1, 0, 0, 0, 0, time.UTC)
+		return start, start.AddDate(0, 1, 0), start.Format("200601")
+	case WindowConfig:
+		return time.Time{}, time.Time{}, ""
+	}
+
+	start := timestamp.Truncate(w.duration)
+	return start, start.Add(w.duration), start.Format("20060102T150405Z")
+}
+
+// Group splits the mapped files into jobs per config and window. Each file's timestamp is parsed using the first of
+// the products it matches, or the only product if a single one is given, and corrected for the site's clock. Files
+// without a config are left out. Jobs are named after their config's directory, so configs of different sources
+// sharing a name are rejected.
+func Group(site string, fileToConfig map[string]string, products []product.Product, window Window, correction mapping.ClockCorrection) ([]Job, error) {
+	type jobKey struct {
+		config string
+		start  time.Time
+		label  string
+	}
+	files := make(map[jobKey][]jobFile)
+	configNames := make(map[string]string)
+
+	for path, config := range fileToConfig {
+		if config == "" {
+			log.Printf("Skipping file '%s': no matching config\n", path)
+			continue
+		}
+
+		configName := filepath.Base(config)
+		if other, ok := configNames[configName]; ok && other != config {
+			return nil, fmt.Errorf("configs %v and %v would both be named %v in jobs", min(config, other), max(config, other), configName)
+		}
+		configNames[configName] = config
+
+		prod, ok := product.ForFile(products, path)
+		if len(products) == 1 {
+			prod, ok = products[0], true
+		}
+		if !ok {
+			return nil, fmt.Errorf("file '%s' does not match any of the products", path)
+		}
+
+		rawTime, err := mapping.ParseProductTime(prod, path)
+		if err != nil {
+			return nil, err
+		}
+		fileTime := correction.Apply(rawTime)
+
+		start, _, label := window.bounds(fileTime)
+		key := jobKey{config: config, start: start, label: label}
+		files[key] = append(files[key], jobFile{path: path, time: fileTime})
+	}
+
+	var res []Job
+	for key, keyFiles := range files {
+		slices.SortFunc(keyFiles, func(a, b jobFile) int {
+			if c := a.time.Compare(b.time); c != 0 {
+				return c
+			}
+			return strings.Compare(a.path, b.path)
+		})
+
+		configName := filepath.Base(key.config)
+		job := Job{
+			Name:       strings.Join([]string{site, configName}, "_"),
+			Site:       site,
+			Config:     key.config,
+			ConfigName: configName,
+		}
+		if key.label != "" {
+			job.Name += "_" + key.label
+		}
+
+		if window.name == WindowConfig {
+			job.Start, job.End = keyFiles[0].time, keyFiles[len(keyFiles)-1].time
+		} else {
+			job.Start, job.End, _ = window.bounds(keyFiles[0].time)
+		}
+
+		for _, file := range keyFiles {
+			job.Files = append(job.Files, file.path)
+		}
+		res = append(res, job)
+	}
+
+	slices.SortFunc(res, func(a, b Job) int {
+		if c := a.Start.Compare(b.Start); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	for i := range res {
+		res[i].Index = i
+	}
+
+	return res, nil
+}
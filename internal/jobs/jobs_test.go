@@ -0,0 +1,136 @@
+package jobs
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/mapping"
+	"git.axiom/axiom/range-series-config-mapper/internal/product"
+)
+
+var testMapping = map[string]string{
+	"/MGS1/RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610.rs": "/MGS1/Config_Auto/20230501T000000Z",
+	"/MGS1/RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_003610.rs": "/MGS1/Config_Auto/20230501T000000Z",
+	"/MGS1/RangeSeries/2023/05/18/Rng_mgs1_2023_05_18_000610.rs": "/MGS1/Config_Auto/20230501T000000Z",
+	"/MGS1/RangeSeries/2023/05/18/Rng_mgs1_2023_05_18_120610.rs": "/MGS1/Config_Operator/20230518T120000Z-20230519T000000Z",
+	"/MGS1/RangeSeries/2023/04/01/Rng_mgs1_2023_04_01_000000.rs": "",
+}
+
+func TestGroup(t *testing.T) {
+	// Define test cases
+	tests := []struct {
+		name      string
+		window    string
+		wantNames []string
+		wantFiles []int
+	}{
+		{
+			name:      "Per config",
+			window:    WindowConfig,
+			wantNames: []string{"MGS1_20230501T000000Z", "MGS1_20230518T120000Z-20230519T000000Z"},
+			wantFiles: []int{3, 1},
+		},
+		{
+			name:      "Per day",
+			window:    WindowDay,
+			wantNames: []string{"MGS1_20230501T000000Z_20230517", "MGS1_20230501T000000Z_20230518", "MGS1_20230518T120000Z-20230519T000000Z_20230518"},
+			wantFiles: []int{2, 1, 1},
+		},
+		{
+			name:      "Per 6 hours",
+			window:    "6h",
+			wantNames: []string{"MGS1_20230501T000000Z_20230517T000000Z", "MGS1_20230501T000000Z_20230517T060000Z", "MGS1_20230501T000000Z_20230518T000000Z", "MGS1_20230518T120000Z-20230519T000000Z_20230518T120000Z"},
+			wantFiles: []int{1, 1, 1, 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window, err := ParseWindow(tt.window)
+			if err != nil {
+				t.Fatalf("ParseWindow() error = %v", err)
+			}
+
+			// Execute test
+			got, err := Group("MGS1", testMapping, []product.Product{product.RangeSeries}, window, mapping.ClockCorrection{})
+			if err != nil {
+				t.Fatalf("Group() error = %v", err)
+			}
+
+			// Assert results
+			var names []string
+			var files []int
+			for i, job := range got {
+				if job.Index != i {
+					t.Errorf("Group() job %d has index %d", i, job.Index)
+				}
+				names = append(names, job.Name)
+				files = append(files, len(job.Files))
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) || !reflect.DeepEqual(files, tt.wantFiles) {
+				t.Errorf("Group() = %v %v, want %v %v", names, files, tt.wantNames, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestGroupNameCollision(t *testing.T) {
+	// Arrange
+	fileToConfig := map[string]string{
+		"/MGS1/RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610.rs": "/MGS1/Config_Auto/20230501T000000Z",
+		"/MGS1/RangeSeries/2023/05/18/Rng_mgs1_2023_05_18_070610.rs": "/MGS1/Configs/20230501T000000Z",
+	}
+
+	// Execute test
+	_, err := Group("MGS1", fileToConfig, []product.Product{product.RangeSeries}, Window{name: WindowConfig}, mapping.ClockCorrection{})
+
+	// Assert results
+	if err == nil || !strings.Contains(err.Error(), "would both be named") {
+		t.Errorf("Group() error = %v, want name collision", err)
+	}
+}
+
+func TestParseWindowInvalid(t *testing.T) {
+	for _, str := range []string{"week", "-1h", ""} {
+		if _, err := ParseWindow(str); err == nil {
+			t.Errorf("ParseWindow(%q) error = nil, want error", str)
+		}
+	}
+}
+
+func TestRender(t *testing.T) {
+	// Arrange
+	job := Job{
+		Name:   "MGS1_20230501T000000Z_20230517",
+		Config: "/MGS1/Config_Auto/20230501T000000Z",
+		Start:  time.Date(2023, 5, 17, 0, 0, 0, 0, time.UTC),
+		End:    time.Date(2023, 5, 18, 0, 0, 0, 0, time.UTC),
+		Files:  []string{"/MGS1/RangeSeries/it's.rs"},
+	}
+
+	for name := range Builtin {
+		t.Run(name, func(t *testing.T) {
+			tmpl, err := ParseTemplate(name)
+			if err != nil {
+				t.Fatalf("ParseTemplate() error = %v", err)
+			}
+
+			// Execute test
+			var out bytes.Buffer
+			if err := tmpl.Render(&out, job); err != nil {
+				t.Fatalf("Render() error = %v", err)
+			}
+
+			// Assert results
+			if !strings.Contains(out.String(), job.Name) {
+				t.Errorf("Render() = %v, want job name", out.String())
+			}
+			if name != "json" && !strings.Contains(out.String(), `'/MGS1/RangeSeries/it'\''s.rs'`) {
+				t.Errorf("Render() = %v, want quoted file", out.String())
+			}
+		})
+	}
+}
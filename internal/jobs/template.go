@@ -0,0 +1,100 @@
+package jobs
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// Template renders a job manifest. Ext is the file extension of the rendered manifests.
+type Template struct {
+	tmpl *template.Template
+	Ext  string
+}
+
+// Runs `$PROCESS <config> <file>` for each file of the job, `echo` by default
+const shellTemplate = `#!/bin/sh
+# Job {{.Name}}: {{len .Files}} file(s) for config {{.Config}}
+set -e
+CONFIG={{shellQuote .Config}}
+for FILE in \
+{{- range .Files}}
+    {{shellQuote .}} \
+{{- end}}
+; do
+    ${PROCESS:-echo} "$CONFIG" "$FILE"
+done
+`
+
+const slurmTemplate = `#!/bin/bash
+#SBATCH --job-name={{.Name}}
+#SBATCH --output={{.Name}}.%j.log
+# {{len .Files}} file(s) for config {{.Config}}, {{.Start.Format "2006-01-02T15:04:05Z"}} to {{.End.Format "2006-01-02T15:04:05Z"}}
+set -e
+CONFIG={{shellQuote .Config}}
+for FILE in \
+{{- range .Files}}
+    {{shellQuote .}} \
+{{- end}}
+; do
+    srun ${PROCESS:-echo} "$CONFIG" "$FILE"
+done
+`
+
+// One JSON object per line, so manifests written to a single stream form NDJSON
+const jsonTemplate = `{{json .}}
+`
+
+// Builtin holds the built-in templates, keyed by name
+var Builtin = map[string]struct {
+	Text string
+	Ext  string
+}{
+	"shell": {shellTemplate, ".sh"},
+	"slurm": {slurmTemplate, ".slurm"},
+	"json":  {jsonTemplate, ".json"},
+}
+
+var templateFuncs = template.FuncMap{
+	"json": func(v any) (string, error) {
+		data, err := json.Marshal(v)
+		return string(data), err
+	},
+	"shellQuote": func(s string) string {
+		return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+	},
+	"base": filepath.Base,
+	"join": strings.Join,
+}
+
+// ParseTemplate parses the named template. Names other than the built-in ones are read as template files, whose
+// extension (ignoring a trailing `.tmpl`) is used for the rendered manifests, e.g. `job.sbatch.tmpl`.
+func ParseTemplate(name string) (Template, error) {
+	text, ext := "", ""
+
+	if builtin, ok := Builtin[name]; ok {
+		text, ext = builtin.Text, builtin.Ext
+	} else {
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return Template{}, err
+		}
+		text, ext = string(data), filepath.Ext(strings.TrimSuffix(filepath.Base(name), ".tmpl"))
+	}
+
+	tmpl, err := template.New(filepath.Base(name)).Funcs(templateFuncs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return Template{}, fmt.Errorf("error parsing template %s: %v", name, err)
+	}
+
+	return Template{tmpl: tmpl, Ext: ext}, nil
+}
+
+// Render writes the manifest of the job
+func (t Template) Render(w io.Writer, job Job) error {
+	return t.tmpl.Execute(w, job)
+}
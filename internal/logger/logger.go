@@ -35,3 +35,16 @@ func (l *TestLogger) Fatalf(msg string, args ...any) {
 	l.Logs = append(l.Logs, fmt.Sprintf(msg, args...))
 	l.FatalCalled = true
 }
+
+// RecordingLogger records fatal messages instead of exiting, so that they can be reported to the caller
+type RecordingLogger struct {
+	Logs []string
+}
+
+func (l *RecordingLogger) Fatal(msg string) {
+	l.Logs = append(l.Logs, msg)
+}
+
+func (l *RecordingLogger) Fatalf(msg string, args ...any) {
+	l.Logs = append(l.Logs, fmt.Sprintf(msg, args...))
+}
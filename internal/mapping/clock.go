@@ -0,0 +1,65 @@
+package mapping
+
+import (
+	"time"
+)
+
+// The largest offset of any timezone from UTC
+const maxZoneOffset = 14 * time.Hour
+
+// ClockRule corrects the timestamps recorded within [Start, End) while the site clock was off. A zero End leaves the
+// rule open-ended.
+type ClockRule struct {
+	Start time.Time
+	End   time.Time
+	// How far the site clock was ahead, or behind if negative
+	Offset time.Duration
+}
+
+func (r ClockRule) contains(timestamp time.Time) bool {
+	return !timestamp.Before(r.Start) && (r.End.IsZero() || timestamp.Before(r.End))
+}
+
+// ClockCorrection converts the timestamps in file names, recorded by the site's clock in its timezone, into UTC. The
+// zero value leaves timestamps unchanged.
+type ClockCorrection struct {
+	// The site's timezone, UTC if nil
+	Location *time.Location
+	// Rule bounds are compared against the recorded timestamps after conversion from the site's timezone
+	Rules []ClockRule
+}
+
+// IsZero reports whether the correction leaves all timestamps unchanged
+func (c ClockCorrection) IsZero() bool {
+	return (c.Location == nil || c.Location == time.UTC) && len(c.Rules) == 0
+}
+
+// Apply corrects a timestamp parsed from a file name as if it were UTC
+func (c ClockCorrection) Apply(raw time.Time) time.Time {
+	res := raw
+	if c.Location != nil {
+		res = time.Date(raw.Year(), raw.Month(), raw.Day(), raw.Hour(), raw.Minute(), raw.Second(), raw.Nanosecond(), c.Location).UTC()
+	}
+
+	for _, rule := range c.Rules {
+		if rule.contains(res) {
+			return res.Add(-rule.Offset)
+		}
+	}
+
+	return res
+}
+
+// MaxShift bounds how far Apply can move a timestamp in either direction
+func (c ClockCorrection) MaxShift() time.Duration {
+	var res time.Duration
+	for _, rule := range c.Rules {
+		res = max(res, rule.Offset, -rule.Offset)
+	}
+
+	if c.Location != nil && c.Location != time.UTC {
+		res += maxZoneOffset
+	}
+
+	return res
+}
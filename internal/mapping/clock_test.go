@@ -0,0 +1,74 @@
+package mapping
+
+import (
+	"testing"
+	"time"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/config_interval"
+	"git.axiom/axiom/range-series-config-mapper/internal/product"
+)
+
+func TestClockCorrectionApply(t *testing.T) {
+	// Arrange
+	losAngeles, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("Failed to load timezone: %v", err)
+	}
+	rules := []ClockRule{
+		{Start: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), Offset: 90 * time.Second},
+		{Start: time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC), Offset: -2 * time.Minute},
+	}
+
+	// Define test cases
+	tests := []struct {
+		name       string
+		correction ClockCorrection
+		raw        time.Time
+		want       time.Time
+	}{
+		{"No correction", ClockCorrection{}, time.Date(2023, 5, 17, 7, 6, 10, 0, time.UTC), time.Date(2023, 5, 17, 7, 6, 10, 0, time.UTC)},
+		{"Daylight saving time", ClockCorrection{Location: losAngeles}, time.Date(2023, 5, 17, 7, 6, 10, 0, time.UTC), time.Date(2023, 5, 17, 14, 6, 10, 0, time.UTC)},
+		{"Standard time", ClockCorrection{Location: losAngeles}, time.Date(2023, 1, 17, 7, 6, 10, 0, time.UTC), time.Date(2023, 1, 17, 15, 6, 10, 0, time.UTC)},
+		{"Clock ahead", ClockCorrection{Rules: rules}, time.Date(2023, 5, 17, 7, 6, 10, 0, time.UTC), time.Date(2023, 5, 17, 7, 4, 40, 0, time.UTC)},
+		{"Clock behind, open-ended", ClockCorrection{Rules: rules}, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), time.Date(2024, 1, 1, 0, 2, 0, 0, time.UTC)},
+		{"Outside rules", ClockCorrection{Rules: rules}, time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{"Rule bounds in UTC", ClockCorrection{Location: losAngeles, Rules: rules}, time.Date(2023, 5, 31, 20, 0, 0, 0, time.UTC), time.Date(2023, 6, 1, 3, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute test
+			got := tt.correction.Apply(tt.raw)
+
+			// Assert results
+			if !got.Equal(tt.want) {
+				t.Errorf("Apply(%v) = %v, want %v", tt.raw, got, tt.want)
+			}
+			if shift := got.Sub(tt.raw).Abs(); shift > tt.correction.MaxShift() {
+				t.Errorf("Apply(%v) shifted by %v, more than MaxShift() = %v", tt.raw, shift, tt.correction.MaxShift())
+			}
+		})
+	}
+}
+
+func TestExplainProductFileWithClockCorrection(t *testing.T) {
+	// Arrange
+	precedence := Precedence{{Name: ConfigKindAuto, Scheme: SchemeAuto, Intervals: []config_interval.ConfigInterval{
+		{Start: time.Date(2023, 5, 17, 7, 5, 0, 0, time.UTC), End: time.Date(2023, 5, 18, 0, 0, 0, 0, time.UTC), Config: "20230517T070500Z"},
+	}}}
+	correction := ClockCorrection{Rules: []ClockRule{{Start: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), Offset: 2 * time.Minute}}}
+
+	// Execute test
+	got := precedence.ExplainProductFile(product.RangeSeries, "/MGS1/RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610.rs", Policy{Clock: correction})
+
+	// Assert results
+	if got.RawTime == nil || !got.RawTime.Equal(time.Date(2023, 5, 17, 7, 6, 10, 0, time.UTC)) {
+		t.Errorf("ExplainProductFile() raw time = %v", got.RawTime)
+	}
+	if got.Time == nil || !got.Time.Equal(time.Date(2023, 5, 17, 7, 4, 10, 0, time.UTC)) {
+		t.Errorf("ExplainProductFile() time = %v", got.Time)
+	}
+	if got.Config != "" || got.Rule != RuleNoCandidate {
+		t.Errorf("ExplainProductFile() = %v (%v), want no config for the corrected time", got.Config, got.Rule)
+	}
+}
@@ -0,0 +1,171 @@
+package mapping
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/product"
+)
+
+// Rules by which a file's config was chosen
+const (
+	RuleOnlyCandidate       = "only_candidate"
+	RuleOperatorOverAuto    = "operator_over_auto"
+	RuleHigherPrecedence    = "higher_precedence"
+	RuleFallback            = "fallback"
+	RuleStaleAuto           = "stale_auto"
+	RuleFirstInOrder        = "first_in_order"
+	RuleNoCandidate         = "no_candidate"
+	RuleUnparsableTimestamp = "unparsable_timestamp"
+)
+
+// ExplainedCandidate is a config whose interval contains a file's timestamp, and why it was chosen or rejected
+type ExplainedCandidate struct {
+	Config string    `json:"config"`
+	Kind   string    `json:"kind"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+	Chosen bool      `json:"chosen"`
+	Reason string    `json:"reason"`
+}
+
+// Explanation records how the config of a product file was determined
+type Explanation struct {
+	File    string `json:"file"`
+	Product string `json:"product"`
+	// The timestamp as it appears in the file name, and as parsed and corrected for the site's clock. The timestamp as
+	// parsed is only given if the correction changed it.
+	TimestampString string     `json:"timestamp_string,omitempty"`
+	RawTime         *time.Time `json:"raw_time,omitempty"`
+	Time            *time.Time `json:"time,omitempty"`
+	// Set if the file was stamped within the boundary grace before the start of its config's interval
+	SnappedTo *time.Time `json:"snapped_to,omitempty"`
+	// Whether the timestamp lies exactly on the start or end of a config interval
+	OnBoundary bool   `json:"on_boundary,omitempty"`
+	Error      string `json:"error,omitempty"`
+	Config     string `json:"config"`
+	Kind       string `json:"kind,omitempty"`
+	Rule       string `json:"rule"`
+	// Whether the file matched an auto config past its maximum validity
+	Stale bool `json:"stale,omitempty"`
+	// The operator window containing the file if it resolved to an auto config there
+	Unapproved string `json:"unapproved,omitempty"`
+	// The fallback that chose the config of a file outside every config interval
+	Fallback   string               `json:"fallback,omitempty"`
+	Candidates []ExplainedCandidate `json:"candidates"`
+}
+
+// ExplainProductFile determines the config of the file the same way as MapProductFiles, recording each step
+func (p Precedence) ExplainProductFile(prod product.Product, productPath string, policy Policy) Explanation {
+	res := Explanation{File: productPath, Product: prod.Name, Candidates: []ExplainedCandidate{}}
+
+	productDateTimeRegex, err := prod.TimestampRegex()
+	if err != nil {
+		res.Rule, res.Error = RuleUnparsableTimestamp, err.Error()
+		return res
+	}
+
+	res.TimestampString, _ = extractTimestampStr(filepath.Base(productPath), productDateTimeRegex)
+	rawTime, err := parseProductTime(filepath.Base(productPath), productDateTimeRegex, prod.TimestampLayout)
+	if err != nil {
+		res.Rule, res.Error = RuleUnparsableTimestamp, err.Error()
+		return res
+	}
+	resolution := p.Resolve(rawTime, policy)
+	if !resolution.Time.Equal(rawTime) {
+		res.RawTime = &rawTime
+	}
+	res.Time = &resolution.Time
+	if resolution.Snapped {
+		res.SnappedTo = &resolution.LookupTime
+	}
+	res.OnBoundary = resolution.OnBoundary
+	res.Stale = resolution.Stale
+	res.Unapproved = resolution.Unapproved
+
+	candidates := resolution.Candidates
+	if len(candidates) == 0 {
+		res.Rule = RuleNoCandidate
+		if resolution.Stale {
+			res.Rule = RuleStaleAuto
+		}
+		if resolution.Fallback != "" {
+			res.Rule, res.Fallback = RuleFallback, resolution.Fallback
+			res.Config, res.Kind = resolution.FallbackInterval.Config, resolution.FallbackKind
+		}
+		res.Candidates = append(res.Candidates, explainStaleCandidates(resolution, policy)...)
+		return res
+	}
+
+	chosen := candidates[0]
+	res.Config, res.Kind = chosen.Interval.Config, chosen.Kind
+	res.Rule = chosenRule(candidates)
+
+	for i, candidate := range candidates {
+		explained := ExplainedCandidate{
+			Config: candidate.Interval.Config,
+			Kind:   candidate.Kind,
+			Start:  candidate.Interval.Start,
+			End:    candidate.Interval.End,
+			Chosen: i == 0,
+		}
+
+		if i == 0 {
+			explained.Reason = ruleDescription(res.Rule)
+		} else if candidate.Kind != chosen.Kind {
+			explained.Reason = fmt.Sprintf("%s configs are overridden by %s config %s", candidate.Kind, chosen.Kind, chosen.Interval.Config)
+		} else {
+			explained.Reason = fmt.Sprintf("overlaps %s config %s, which comes first", chosen.Kind, chosen.Interval.Config)
+		}
+		res.Candidates = append(res.Candidates, explained)
+	}
+	res.Candidates = append(res.Candidates, explainStaleCandidates(resolution, policy)...)
+
+	return res
+}
+
+// explainStaleCandidates lists the auto configs unmapped as stale as rejected candidates
+func explainStaleCandidates(resolution Resolution, policy Policy) []ExplainedCandidate {
+	var res []ExplainedCandidate
+
+	for _, candidate := range resolution.StaleCandidates {
+		res = append(res, ExplainedCandidate{
+			Config: candidate.Interval.Config,
+			Kind:   candidate.Kind,
+			Start:  candidate.Interval.Start,
+			End:    candidate.Interval.End,
+			Reason: fmt.Sprintf("past max_auto_validity of %v", policy.MaxAutoValidity),
+		})
+	}
+
+	return res
+}
+
+// chosenRule names the rule by which the first candidate won
+func chosenRule(candidates []Candidate) string {
+	if len(candidates) == 1 {
+		return RuleOnlyCandidate
+	}
+	if candidates[0].Kind == ConfigKindOperator && candidates[len(candidates)-1].Kind == ConfigKindAuto {
+		return RuleOperatorOverAuto
+	}
+	if candidates[0].Kind != candidates[len(candidates)-1].Kind {
+		return RuleHigherPrecedence
+	}
+	return RuleFirstInOrder
+}
+
+func ruleDescription(rule string) string {
+	switch rule {
+	case RuleOnlyCandidate:
+		return "only config interval containing the timestamp"
+	case RuleOperatorOverAuto:
+		return "operator configs take precedence over auto configs"
+	case RuleHigherPrecedence:
+		return "comes from the config source with the highest precedence"
+	case RuleFirstInOrder:
+		return "first of the overlapping configs in order of start time"
+	}
+	return ""
+}
@@ -0,0 +1,87 @@
+package mapping
+
+import (
+	"testing"
+	"time"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/config_interval"
+	"git.axiom/axiom/range-series-config-mapper/internal/product"
+)
+
+func TestExplainProductFile(t *testing.T) {
+	// Arrange
+	autoConfigIntervals := []config_interval.ConfigInterval{
+		{
+			Start:  time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC),
+			End:    time.Date(2023, 5, 20, 0, 0, 0, 0, time.UTC),
+			Config: "20230501T000000Z",
+		},
+	}
+	operatorConfigIntervals := []config_interval.ConfigInterval{
+		{
+			Start:  time.Date(2023, 5, 10, 0, 0, 0, 0, time.UTC),
+			End:    time.Date(2023, 5, 15, 0, 0, 0, 0, time.UTC),
+			Config: "20230510T000000Z-20230515T000000Z",
+		},
+	}
+	precedence := DefaultPrecedence(autoConfigIntervals, operatorConfigIntervals)
+	policy := Policy{}
+
+	// Define test cases
+	tests := []struct {
+		name           string
+		file           string
+		wantConfig     string
+		wantRule       string
+		wantCandidates int
+	}{
+		{"Operator overrides auto", "Rng_mgs1_2023_05_12_000000.rs", "20230510T000000Z-20230515T000000Z", RuleOperatorOverAuto, 2},
+		{"Only auto", "Rng_mgs1_2023_05_17_070610.rs", "20230501T000000Z", RuleOnlyCandidate, 1},
+		{"Before any config", "Rng_mgs1_2023_04_01_000000.rs", "", RuleNoCandidate, 0},
+		{"Unparsable timestamp", "Rng_mgs1_2023_13_01_000000.rs", "", RuleUnparsableTimestamp, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute test
+			got := precedence.ExplainProductFile(product.RangeSeries, "/MGS1/RangeSeries/"+tt.file, policy)
+
+			// Assert results
+			if got.Config != tt.wantConfig || got.Rule != tt.wantRule || len(got.Candidates) != tt.wantCandidates {
+				t.Errorf("ExplainProductFile() = %+v, want config %v, rule %v and %d candidates", got, tt.wantConfig, tt.wantRule, tt.wantCandidates)
+			}
+			if tt.wantRule == RuleUnparsableTimestamp && (got.Error == "" || got.TimestampString != "2023_13_01_000000") {
+				t.Errorf("ExplainProductFile() = %+v, want error and raw timestamp", got)
+			}
+			if len(got.Candidates) > 1 && (!got.Candidates[0].Chosen || got.Candidates[1].Chosen || got.Candidates[1].Reason == "") {
+				t.Errorf("ExplainProductFile() candidates = %+v", got.Candidates)
+			}
+
+			// The explanation agrees with the mapping
+			if matchingConfig := precedence.MapProductFiles(product.RangeSeries, []string{got.File}, policy)[got.File]; matchingConfig != got.Config {
+				t.Errorf("MapProductFiles() = %v, explanation = %v", matchingConfig, got.Config)
+			}
+		})
+	}
+}
+
+func TestExplainProductFileStale(t *testing.T) {
+	// Arrange
+	precedence := Precedence{
+		{Name: ConfigKindAuto, Scheme: SchemeAuto, Intervals: []config_interval.ConfigInterval{
+			{Start: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), Config: "20230501T000000Z"},
+		}},
+	}
+	policy := Policy{MaxAutoValidity: 30 * 24 * time.Hour, Stale: StaleUnmap}
+
+	// Execute test
+	got := precedence.ExplainProductFile(product.RangeSeries, "/MGS1/RangeSeries/Rng_mgs1_2023_08_01_000000.rs", policy)
+
+	// Assert results
+	if got.Config != "" || got.Rule != RuleStaleAuto || !got.Stale {
+		t.Errorf("ExplainProductFile() = %+v, want no config and rule %v", got, RuleStaleAuto)
+	}
+	if len(got.Candidates) != 1 || got.Candidates[0].Config != "20230501T000000Z" || got.Candidates[0].Chosen || got.Candidates[0].Reason != "past max_auto_validity of 720h0m0s" {
+		t.Errorf("ExplainProductFile() candidates = %+v, want the stale auto config rejected", got.Candidates)
+	}
+}
@@ -11,6 +11,7 @@ import (
 
 	"git.axiom/axiom/range-series-config-mapper/internal/config_interval"
 	"git.axiom/axiom/range-series-config-mapper/internal/logger"
+	"git.axiom/axiom/range-series-config-mapper/internal/product"
 )
 
 const (
@@ -26,8 +27,8 @@ const (
 )
 
 const (
-	rangeSeriesDateTimePattern = `\d{4}_\d{2}_\d{2}_\d{6}`
-	rangeSeriesTimeLayout      = "2006_01_02_150405"
+	ConfigKindOperator = "operator"
+	ConfigKindAuto     = "auto"
 )
 
 var timeNow = func() time.Time {
@@ -62,31 +63,29 @@ func extractTimestampStr(str string, regex *regexp.Regexp) (string, error) {
 }
 
 func BuildOperatorConfigIntervals(configs []string) []config_interval.ConfigInterval {
-	res := []config_interval.ConfigInterval{}
-
-	configs = slices.Clone(configs)
+	res, err := buildOperatorConfigIntervals(configs)
+	if err != nil {
+		log.Fatalf("Error parsing Operator Config: %v", err)
+	}
 
-	// Ensure configs are sorted
-	slices.SortFunc(configs, func(a, b string) int {
-		// Standard string sort, except when `present` is in the string.
-		// `present` should be considered greater than any valid timestamp
-		if strings.Contains(a, presentToken) && !strings.Contains(b, presentToken) {
-			return 1
-		} else if !strings.Contains(a, presentToken) && strings.Contains(b, presentToken) {
-			return -1
-		}
+	return res
+}
 
-		// If both or neither timestamp contains present, sort as regular strings
-		return strings.Compare(a, b)
-	})
+// buildOperatorConfigIntervals builds the intervals of operator configs, or returns an error for the first config whose
+// name does not give a start and end time
+func buildOperatorConfigIntervals(configs []string) ([]config_interval.ConfigInterval, error) {
+	res := []config_interval.ConfigInterval{}
 
 	for _, configPath := range configs {
 		configFileName := filepath.Base(configPath)
 		timeComponents := strings.Split(configFileName, operatorConfigTimeDelimiter)
+		if len(timeComponents) <= configEndTimeIndex {
+			return nil, fmt.Errorf("operator config %v has no end time", configPath)
+		}
 
 		startTime, err := parseConfigDateTime(timeComponents[configStartTimeIndex], configDateTimePattern)
 		if err != nil {
-			log.Fatalf("Error parsing Operator Config start time: %v", err)
+			return nil, fmt.Errorf("error parsing start time of operator config %v: %v", configPath, err)
 		}
 
 		var endTime time.Time
@@ -96,7 +95,7 @@ func BuildOperatorConfigIntervals(configs []string) []config_interval.ConfigInte
 		} else {
 			endTime, err = parseConfigDateTime(timeComponents[configEndTimeIndex], configDateTimePattern)
 			if err != nil {
-				log.Fatalf("Error parsing Operator Config end time: %v", err)
+				return nil, fmt.Errorf("error parsing end time of operator config %v: %v", configPath, err)
 			}
 		}
 
@@ -109,7 +108,19 @@ func BuildOperatorConfigIntervals(configs []string) []config_interval.ConfigInte
 		res = append(res, timeInterval)
 	}
 
-	return res
+	// Ensure configs are sorted by their start time, rather than their path, which may differ in parent directories.
+	// Configs with the same start time are sorted by end time, then path.
+	slices.SortFunc(res, func(a, b config_interval.ConfigInterval) int {
+		if c := a.Start.Compare(b.Start); c != 0 {
+			return c
+		}
+		if c := a.End.Compare(b.End); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Config, b.Config)
+	})
+
+	return res, nil
 }
 
 func ValidateOperatorConfigs(configs []config_interval.ConfigInterval, logger logger.Logger) {
@@ -127,15 +138,23 @@ func ValidateOperatorConfigs(configs []config_interval.ConfigInterval, logger lo
 }
 
 func BuildAutoConfigIntervals(configs []string) []config_interval.ConfigInterval {
-	res := []config_interval.ConfigInterval{}
+	res, err := buildAutoConfigIntervals(configs)
+	if err != nil {
+		log.Fatalf("Error parsing Autodetected Config start time: %v", err)
+	}
 
-	// Ensure configs are sorted
-	slices.Sort(configs)
+	return res
+}
 
-	for i, configPath := range configs {
-		configTime, err := parseConfigDateTime(configPath, configDateTimePattern)
+// buildAutoConfigIntervals builds the intervals of auto configs, or returns an error for the first config whose name
+// does not give a start time
+func buildAutoConfigIntervals(configs []string) ([]config_interval.ConfigInterval, error) {
+	res := []config_interval.ConfigInterval{}
+
+	for _, configPath := range configs {
+		configTime, err := parseConfigDateTime(filepath.Base(configPath), configDateTimePattern)
 		if err != nil {
-			log.Fatalf("Error parsing Autodetected Config start time: %v", err)
+			return nil, fmt.Errorf("error parsing start time of auto config %v: %v", configPath, err)
 		}
 
 		// Create new time interval
@@ -145,67 +164,103 @@ func BuildAutoConfigIntervals(configs []string) []config_interval.ConfigInterval
 			Config: configPath,
 		}
 		res = append(res, timeInterval)
+	}
 
-		// Set end time of previous interval to start time of current interval
-		if i > 0 {
-			res[i-1].End = configTime
+	// Ensure configs are sorted by their start time, rather than their path, which may differ in parent directories.
+	// Configs with the same start time are sorted by path.
+	slices.SortFunc(res, func(a, b config_interval.ConfigInterval) int {
+		if c := a.Start.Compare(b.Start); c != 0 {
+			return c
 		}
+		return strings.Compare(a.Config, b.Config)
+	})
+
+	// Set end time of each interval to start time of the next interval
+	for i := 1; i < len(res); i++ {
+		res[i-1].End = res[i].Start
 	}
 
-	return res
+	return res, nil
 }
 
-func getMatchingConfig(timestamp time.Time, autoConfigTimeIntervals, operatorConfigTimeIntervals []config_interval.ConfigInterval) string {
-	for _, timeInterval := range operatorConfigTimeIntervals {
-		if timeInterval.ContainsTime(timestamp) {
-			return timeInterval.Config
+// DuplicateStarts groups the configs of the intervals that share a start time, in order of start time. Of auto configs
+// sharing a start time, only the last is mapped to.
+func DuplicateStarts(timeIntervals []config_interval.ConfigInterval) [][]string {
+	var res [][]string
+
+	byStart := make(map[time.Time][]string)
+	var starts []time.Time
+	for _, timeInterval := range timeIntervals {
+		start := timeInterval.Start.UTC()
+		if _, ok := byStart[start]; !ok {
+			starts = append(starts, start)
 		}
+		byStart[start] = append(byStart[start], timeInterval.Config)
 	}
 
-	for _, timeInterval := range autoConfigTimeIntervals {
-		if timeInterval.ContainsTime(timestamp) {
-			return timeInterval.Config
+	slices.SortFunc(starts, func(a, b time.Time) int {
+		return a.Compare(b)
+	})
+	for _, start := range starts {
+		if len(byStart[start]) > 1 {
+			res = append(res, byStart[start])
 		}
 	}
 
-	// Return an empty string is there is no matching config
-	return ""
+	return res
 }
 
-func CreateRangeSeriesToConfigMap(rangeSeriesFiles []string, autoConfigTimeIntervals, operatorConfigTimeIntervals []config_interval.ConfigInterval) map[string]string {
-	log.Println("Computing RangeSeries:Config mapping...")
+// Candidate is a config whose interval contains a timestamp
+type Candidate struct {
+	Interval config_interval.ConfigInterval
+	// The name of the config source, e.g. `operator`
+	Kind string
+}
 
-	result := make(map[string]string)
+// ParseTimestamp parses a user-supplied timestamp, either in RFC 3339 or in the config directory name layout
+func ParseTimestamp(str string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, str); err == nil {
+		return t.UTC(), nil
+	}
 
-	rangeSeriesDateTimeRegex, err := regexp.Compile(rangeSeriesDateTimePattern)
+	t, err := time.Parse(configTimeLayout, str)
 	if err != nil {
-		log.Fatalf("Error compiling rangeSeriesDateTimeRegex: %v", err)
+		return time.Time{}, fmt.Errorf("invalid timestamp '%s': expected RFC 3339 or %s", str, configTimeLayout)
 	}
+	return t, nil
+}
 
-	// Iterate over each range series file
-	for _, rangeSeriesPath := range rangeSeriesFiles {
-		// 1. Extract base file name
-		rangeSeriesName := filepath.Base(rangeSeriesPath)
-
-		// 2. Parse timestamp from filename
-		rangeSeriesTimeStr, err := extractTimestampStr(rangeSeriesName, rangeSeriesDateTimeRegex)
-		if err != nil {
-			log.Printf("Skipping RangeSeries file '%s': problem extracting timestamp from filename: %v\n", rangeSeriesName, err)
-			continue
-		}
+func parseProductTime(productName string, timestampRegex *regexp.Regexp, timestampLayout string) (time.Time, error) {
+	productTimeStr, err := extractTimestampStr(productName, timestampRegex)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("problem extracting timestamp from filename: %v", err)
+	}
 
-		rangeSeriesTime, err := time.Parse(rangeSeriesTimeLayout, rangeSeriesTimeStr)
-		if err != nil {
-			log.Printf("Skipping RangeSeries file '%s': problem parsing filename timestamp: %v\n", rangeSeriesName, err)
-			continue
-		}
+	productTime, err := time.Parse(timestampLayout, productTimeStr)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("problem parsing filename timestamp: %v", err)
+	}
 
-		// 3. Retrieve corresponding config file
-		matchingConfig := getMatchingConfig(rangeSeriesTime, autoConfigTimeIntervals, operatorConfigTimeIntervals)
+	return productTime, nil
+}
 
-		// 4. Add key file path w/ value config file
-		result[rangeSeriesPath] = matchingConfig
+// ParseProductTime parses the timestamp from the name of a product file
+func ParseProductTime(prod product.Product, productPath string) (time.Time, error) {
+	timestampRegex, err := prod.TimestampRegex()
+	if err != nil {
+		return time.Time{}, err
 	}
 
-	return result
+	return parseProductTime(filepath.Base(productPath), timestampRegex, prod.TimestampLayout)
+}
+
+func CreateRangeSeriesToConfigMap(rangeSeriesFiles []string, autoConfigTimeIntervals, operatorConfigTimeIntervals []config_interval.ConfigInterval) map[string]string {
+	return DefaultPrecedence(autoConfigTimeIntervals, operatorConfigTimeIntervals).MapProductFiles(product.RangeSeries, rangeSeriesFiles, Policy{})
+}
+
+// ExcludeConfigs returns the intervals whose config is not one of excludedConfigs
+func ExcludeConfigs(configIntervals []config_interval.ConfigInterval, excludedConfigs []string) []config_interval.ConfigInterval {
+	return slices.DeleteFunc(slices.Clone(configIntervals), func(timeInterval config_interval.ConfigInterval) bool {
+		return slices.Contains(excludedConfigs, timeInterval.Config)
+	})
 }
@@ -196,6 +196,99 @@ func TestBuildAutoConfigIntervals(t *testing.T) {
 	}
 }
 
+func TestBuildAutoConfigIntervalsSortsByTime(t *testing.T) {
+	// Override the timeNow function in the test environment
+	originalTimeNow := timeNow
+	timeNow = mockTimeNow
+
+	// Ensure we reset timeNow after the test
+	defer func() { timeNow = originalTimeNow }()
+
+	// Mock inputs, whose paths sort differently from their timestamps
+	configs := []string{
+		"/archive/MGS1/Config_Auto/b/20230101T000000Z",
+		"/archive/MGS1/Config_Auto/a/20230102T000000Z",
+	}
+
+	got := BuildAutoConfigIntervals(configs)
+
+	want := []string{"/archive/MGS1/Config_Auto/b/20230101T000000Z", "/archive/MGS1/Config_Auto/a/20230102T000000Z"}
+	if len(got) != len(want) || got[0].Config != want[0] || got[1].Config != want[1] {
+		t.Fatalf("BuildAutoConfigIntervals() = %v, want configs %v", got, want)
+	}
+	if !got[0].End.Equal(got[1].Start) {
+		t.Errorf("BuildAutoConfigIntervals() first interval ends at %v, want %v", got[0].End, got[1].Start)
+	}
+}
+
+func TestBuildOperatorConfigIntervalsSortsByTime(t *testing.T) {
+	// Arrange, with paths that sort differently from their timestamps
+	configs := []string{
+		"/archive/MGS1/Config_Operator/b/20230101T000000Z-20230102T000000Z",
+		"/archive/MGS1/Config_Operator/a/20230102T000000Z-20230103T000000Z",
+		"/archive/MGS1/Config_Operator/a/20230103T000000Z-present",
+	}
+
+	// Execute test
+	got := BuildOperatorConfigIntervals(configs)
+
+	// Assert results
+	var gotConfigs []string
+	for _, timeInterval := range got {
+		gotConfigs = append(gotConfigs, timeInterval.Config)
+	}
+	if !reflect.DeepEqual(gotConfigs, configs) {
+		t.Errorf("BuildOperatorConfigIntervals() configs = %v, want %v", gotConfigs, configs)
+	}
+	for i := 1; i < len(got); i++ {
+		if got[i].Start.Before(got[i-1].End) {
+			t.Errorf("BuildOperatorConfigIntervals() interval %v overlaps with %v", got[i].Config, got[i-1].Config)
+		}
+	}
+}
+
+func TestBuildConfigIntervalsErrors(t *testing.T) {
+	// Define test cases
+	tests := []struct {
+		name    string
+		scheme  string
+		configs []string
+	}{
+		{"Auto config without a start time", SchemeAuto, []string{"/archive/MGS1/Config_Auto/latest"}},
+		{"Operator config without an end time", SchemeOperator, []string{"/archive/MGS1/Config_Operator/20230101T000000Z"}},
+		{"Operator config with an invalid end time", SchemeOperator, []string{"/archive/MGS1/Config_Operator/20230101T000000Z-later"}},
+		{"Unknown scheme", "reprocess", []string{"/archive/MGS1/Config_Reprocess/20230101T000000Z"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute test
+			_, err := BuildConfigIntervals(tt.scheme, tt.configs)
+
+			// Assert results
+			if err == nil {
+				t.Errorf("BuildConfigIntervals() error = nil, want an error")
+			}
+		})
+	}
+}
+
+func TestDuplicateStarts(t *testing.T) {
+	// Mock inputs
+	timeIntervals := []config_interval.ConfigInterval{
+		{Start: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Config: "a/20230101T000000Z"},
+		{Start: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), Config: "b/20230101T000000Z"},
+		{Start: time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC), Config: "a/20230102T000000Z"},
+	}
+
+	got := DuplicateStarts(timeIntervals)
+
+	want := [][]string{{"a/20230101T000000Z", "b/20230101T000000Z"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DuplicateStarts() = %v, want %v", got, want)
+	}
+}
+
 func TestValidateOperatorConfigs(t *testing.T) {
 	// Define test cases
 	tests := []struct {
@@ -303,8 +396,8 @@ func TestValidateOperatorConfigs(t *testing.T) {
 			name: "Invalid configs: start date in the future",
 			configs: []config_interval.ConfigInterval{
 				{
-					Start:  time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC),
-					End:    time.Date(2026, 1, 5, 0, 0, 0, 0, time.UTC),
+					Start:  time.Now().UTC().AddDate(1, 0, 0),
+					End:    time.Now().UTC().AddDate(2, 0, 0),
 					Config: "20250105T00000Z-20260105T000000Z",
 				},
 			},
@@ -322,3 +415,159 @@ func TestValidateOperatorConfigs(t *testing.T) {
 		})
 	}
 }
+
+func TestExcludeConfigs(t *testing.T) {
+	// Arrange
+	configIntervals := []config_interval.ConfigInterval{
+		{
+			Start:  time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC),
+			End:    time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+			Config: "20230101T000000Z",
+		},
+		{
+			Start:  time.Date(2023, 1, 2, 0, 0, 0, 0, time.UTC),
+			End:    time.Date(2023, 1, 3, 0, 0, 0, 0, time.UTC),
+			Config: "20230102T000000Z",
+		},
+	}
+
+	expected := configIntervals[1:]
+
+	// Execute test
+	got := ExcludeConfigs(configIntervals, []string{"20230101T000000Z"})
+
+	// Assert results
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ExcludeConfigs() = %v, want %v", got, expected)
+	}
+
+	// The excluded interval's time span must not be taken over by a neighbouring config
+	if matchingConfig := DefaultPrecedence(got, nil).Config(time.Date(2023, 1, 1, 12, 0, 0, 0, time.UTC)); matchingConfig != "" {
+		t.Errorf("Config() = %v, want no config", matchingConfig)
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	// Arrange
+	autoConfigIntervals := []config_interval.ConfigInterval{
+		{
+			Start:  time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC),
+			End:    time.Date(2023, 5, 20, 0, 0, 0, 0, time.UTC),
+			Config: "20230501T000000Z",
+		},
+	}
+	operatorConfigIntervals := []config_interval.ConfigInterval{
+		{
+			Start:  time.Date(2023, 5, 10, 0, 0, 0, 0, time.UTC),
+			End:    time.Date(2023, 5, 15, 0, 0, 0, 0, time.UTC),
+			Config: "20230510T000000Z-20230515T000000Z",
+		},
+	}
+
+	// Define test cases
+	tests := []struct {
+		name      string
+		timestamp time.Time
+		want      []string
+	}{
+		{"Operator config preferred", time.Date(2023, 5, 12, 0, 0, 0, 0, time.UTC), []string{"20230510T000000Z-20230515T000000Z", "20230501T000000Z"}},
+		{"Auto config only", time.Date(2023, 5, 17, 0, 0, 0, 0, time.UTC), []string{"20230501T000000Z"}},
+		{"No config", time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute test
+			var got []string
+			for _, candidate := range DefaultPrecedence(autoConfigIntervals, operatorConfigIntervals).Candidates(tt.timestamp) {
+				got = append(got, candidate.Interval.Config)
+			}
+
+			// Assert results
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Candidates() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtendOpenIntervals(t *testing.T) {
+	// Arrange
+	builtAt := time.Date(2023, 5, 20, 0, 0, 0, 0, time.UTC)
+	later := builtAt.Add(time.Hour)
+	originalTimeNow := timeNow
+	timeNow = func() time.Time { return builtAt }
+	defer func() { timeNow = originalTimeNow }()
+
+	autoConfigIntervals, err := BuildConfigIntervals(SchemeAuto, []string{"20230501T000000Z", "20230510T000000Z"})
+	if err != nil {
+		t.Fatalf("BuildConfigIntervals() error = %v", err)
+	}
+	operatorConfigIntervals, err := BuildConfigIntervals(SchemeOperator, []string{"20230501T000000Z-20230502T000000Z", "20230515T000000Z-present"})
+	if err != nil {
+		t.Fatalf("BuildConfigIntervals() error = %v", err)
+	}
+	// A manifest entry with an explicit end is not extended, even though it is the latest of an auto source
+	manifestIntervals := []config_interval.ConfigInterval{
+		{Start: time.Date(2023, 5, 12, 0, 0, 0, 0, time.UTC), End: time.Date(2023, 5, 14, 0, 0, 0, 0, time.UTC), Config: "/mnt/configs/calibration"},
+	}
+	precedence := Precedence{
+		{Name: ConfigKindOperator, Scheme: SchemeOperator, Intervals: operatorConfigIntervals},
+		{Name: ConfigKindAuto, Scheme: SchemeAuto, Intervals: autoConfigIntervals},
+		{Name: "calibration", Scheme: SchemeAuto, Intervals: manifestIntervals},
+	}
+
+	// Execute test
+	timeNow = func() time.Time { return later }
+	precedence.ExtendOpenIntervals()
+
+	// Assert results
+	wantEnds := []time.Time{time.Date(2023, 5, 10, 0, 0, 0, 0, time.UTC), later, time.Date(2023, 5, 2, 0, 0, 0, 0, time.UTC), later, time.Date(2023, 5, 14, 0, 0, 0, 0, time.UTC)}
+	gotEnds := []time.Time{autoConfigIntervals[0].End, autoConfigIntervals[1].End, operatorConfigIntervals[0].End, operatorConfigIntervals[1].End, manifestIntervals[0].End}
+	if !reflect.DeepEqual(gotEnds, wantEnds) {
+		t.Errorf("ExtendOpenIntervals() ends = %v, want %v", gotEnds, wantEnds)
+	}
+}
+
+func TestPrecedenceCandidates(t *testing.T) {
+	// Arrange
+	auto := Source{Name: ConfigKindAuto, Scheme: SchemeAuto, Intervals: []config_interval.ConfigInterval{
+		{Start: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2023, 5, 20, 0, 0, 0, 0, time.UTC), Config: "20230501T000000Z"},
+	}}
+	operator := Source{Name: ConfigKindOperator, Scheme: SchemeOperator, Intervals: []config_interval.ConfigInterval{
+		{Start: time.Date(2023, 5, 10, 0, 0, 0, 0, time.UTC), End: time.Date(2023, 5, 15, 0, 0, 0, 0, time.UTC), Config: "20230510T000000Z-20230515T000000Z"},
+	}}
+	reprocess := Source{Name: "reprocess", Scheme: SchemeOperator, Intervals: []config_interval.ConfigInterval{
+		{Start: time.Date(2023, 5, 12, 0, 0, 0, 0, time.UTC), End: time.Date(2023, 5, 18, 0, 0, 0, 0, time.UTC), Config: "20230512T000000Z-20230518T000000Z"},
+	}}
+	timestamp := time.Date(2023, 5, 13, 0, 0, 0, 0, time.UTC)
+
+	// Define test cases
+	tests := []struct {
+		name       string
+		precedence Precedence
+		want       []string
+		wantKind   string
+	}{
+		{"Reprocess outranks both", Precedence{reprocess, operator, auto}, []string{"20230512T000000Z-20230518T000000Z", "20230510T000000Z-20230515T000000Z", "20230501T000000Z"}, "reprocess"},
+		{"Auto only", Precedence{auto}, []string{"20230501T000000Z"}, ConfigKindAuto},
+		{"Operator only", Precedence{operator}, []string{"20230510T000000Z-20230515T000000Z"}, ConfigKindOperator},
+		{"Auto over operator", Precedence{auto, operator}, []string{"20230501T000000Z", "20230510T000000Z-20230515T000000Z"}, ConfigKindAuto},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute test
+			var got []string
+			for _, candidate := range tt.precedence.Candidates(timestamp) {
+				got = append(got, candidate.Interval.Config)
+			}
+			_, gotKind, _ := tt.precedence.Match(timestamp)
+
+			// Assert results
+			if !reflect.DeepEqual(got, tt.want) || gotKind != tt.wantKind {
+				t.Errorf("Candidates() = %v, kind %v, want %v, kind %v", got, gotKind, tt.want, tt.wantKind)
+			}
+		})
+	}
+}
@@ -0,0 +1,198 @@
+package mapping
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/config_interval"
+	"git.axiom/axiom/range-series-config-mapper/internal/product"
+)
+
+// Naming schemes of config directories
+const (
+	// Directories named by their start time, e.g. `20230501T000000Z`. Each config lasts until the next one starts, and
+	// the latest until now.
+	SchemeAuto = "auto"
+	// Directories named by their start and end time, e.g. `20230510T000000Z-20230515T000000Z`, or ending in `present`
+	SchemeOperator = "operator"
+)
+
+// Schemes lists the supported naming schemes
+var Schemes = []string{SchemeAuto, SchemeOperator}
+
+// Source holds the config intervals of a named config source, e.g. the operator configs of `Config_Operator`
+type Source struct {
+	Name      string
+	Scheme    string
+	Intervals []config_interval.ConfigInterval
+	// The intervals of configs left out of the mapping, e.g. incomplete configs. Files within them are not mapped, not
+	// even by a fallback.
+	Excluded []config_interval.ConfigInterval
+}
+
+// Precedence is an ordered list of config sources. A timestamp resolves to a config of the first source with an
+// interval containing it.
+type Precedence []Source
+
+// DefaultPrecedence prefers operator configs over auto configs
+func DefaultPrecedence(autoConfigTimeIntervals, operatorConfigTimeIntervals []config_interval.ConfigInterval) Precedence {
+	return Precedence{
+		{Name: ConfigKindOperator, Scheme: SchemeOperator, Intervals: operatorConfigTimeIntervals},
+		{Name: ConfigKindAuto, Scheme: SchemeAuto, Intervals: autoConfigTimeIntervals},
+	}
+}
+
+// BuildConfigIntervals builds the intervals of configs named according to the scheme. It returns an error if a config's
+// name does not follow the scheme.
+func BuildConfigIntervals(scheme string, configs []string) ([]config_interval.ConfigInterval, error) {
+	switch scheme {
+	case SchemeAuto:
+		res, err := buildAutoConfigIntervals(configs)
+		if n := len(res); n > 0 {
+			res[n-1].Open = true
+		}
+		return res, err
+	case SchemeOperator:
+		res, err := buildOperatorConfigIntervals(configs)
+		for i, timeInterval := range res {
+			res[i].Open = strings.HasSuffix(filepath.Base(timeInterval.Config), operatorConfigTimeDelimiter+presentToken)
+		}
+		return res, err
+	}
+	return nil, fmt.Errorf("unknown naming scheme '%s', supported values are %v", scheme, Schemes)
+}
+
+// Source returns the source with the name
+func (p Precedence) Source(name string) (Source, bool) {
+	for _, source := range p {
+		if source.Name == name {
+			return source, true
+		}
+	}
+	return Source{}, false
+}
+
+// Candidates returns the intervals containing the timestamp in order of precedence, each source's in the order given.
+// The first candidate is the matching config.
+func (p Precedence) Candidates(timestamp time.Time) []Candidate {
+	var res []Candidate
+
+	for _, source := range p {
+		for _, timeInterval := range source.Intervals {
+			if timeInterval.ContainsTime(timestamp) {
+				res = append(res, Candidate{Interval: timeInterval, Kind: source.Name})
+			}
+		}
+	}
+
+	return res
+}
+
+// Match finds the interval containing the timestamp with the highest precedence. It also returns the name of the
+// source it came from.
+func (p Precedence) Match(timestamp time.Time) (config_interval.ConfigInterval, string, bool) {
+	candidates := p.Candidates(timestamp)
+	if len(candidates) == 0 {
+		return config_interval.ConfigInterval{}, "", false
+	}
+
+	return candidates[0].Interval, candidates[0].Kind, true
+}
+
+// Config returns the config at the timestamp, or an empty string if there is no matching config
+func (p Precedence) Config(timestamp time.Time) string {
+	timeInterval, _, _ := p.Match(timestamp)
+	return timeInterval.Config
+}
+
+// ExtendOpenIntervals moves the end of the open-ended intervals, e.g. those of the latest auto config, of operator configs
+// ending in `present` and of manifest entries without an end, to the current time. Long-running processes call it so
+// that files stamped after the intervals were built still match.
+func (p Precedence) ExtendOpenIntervals() {
+	now := timeNow().UTC().Truncate(time.Millisecond * 1000)
+
+	for _, source := range p {
+		for _, timeIntervals := range [][]config_interval.ConfigInterval{source.Intervals, source.Excluded} {
+			for i, timeInterval := range timeIntervals {
+				if timeInterval.Open {
+					timeIntervals[i].End = now
+				}
+			}
+		}
+	}
+}
+
+// Extended returns a copy of the precedence whose open-ended intervals end at the current time. Unlike
+// ExtendOpenIntervals, it leaves p unchanged, so that it can be shared between concurrent lookups.
+func (p Precedence) Extended() Precedence {
+	res := make(Precedence, len(p))
+	for i, source := range p {
+		source.Intervals, source.Excluded = slices.Clone(source.Intervals), slices.Clone(source.Excluded)
+		res[i] = source
+	}
+
+	res.ExtendOpenIntervals()
+	return res
+}
+
+// MapProductFiles maps each product file to its config, resolved according to the policy. Files whose timestamp cannot
+// be parsed are left out.
+func (p Precedence) MapProductFiles(prod product.Product, productFiles []string, policy Policy) map[string]string {
+	log.Printf("Computing %s:Config mapping...\n", prod.Name)
+
+	result := make(map[string]string)
+	unapprovedCount := 0
+
+	productDateTimeRegex, err := prod.TimestampRegex()
+	if err != nil {
+		log.Fatalf("Error compiling %s timestamp pattern: %v", prod.Name, err)
+	}
+
+	// Iterate over each product file
+	for _, productPath := range productFiles {
+		// 1. Extract base file name
+		productName := filepath.Base(productPath)
+
+		// 2. Parse timestamp from filename
+		productTime, err := parseProductTime(productName, productDateTimeRegex, prod.TimestampLayout)
+		if err != nil {
+			log.Printf("Skipping %s file '%s': %v\n", prod.Name, productName, err)
+			continue
+		}
+
+		// 3. Retrieve corresponding config file
+		resolution := p.Resolve(productTime, policy)
+		matchingConfig := resolution.Config()
+		if resolution.Snapped {
+			log.Printf("Snapped %s file '%s' to config %v starting at %v\n", prod.Name, productName, matchingConfig, resolution.LookupTime.Format(time.RFC3339))
+		}
+		if resolution.Fallback != "" {
+			log.Printf("%s file '%s' lies outside every config interval, mapped to the %s config %v\n", prod.Name, productName, resolution.Fallback, matchingConfig)
+		}
+		if resolution.Stale && matchingConfig == "" {
+			log.Printf("Warning: %s file '%s' is left unmapped, its auto config started more than %v before it\n", prod.Name, productName, policy.MaxAutoValidity)
+		} else if resolution.Stale {
+			log.Printf("Warning: %s file '%s' is mapped to auto config %v, which started more than %v before it\n", prod.Name, productName, matchingConfig, policy.MaxAutoValidity)
+		}
+		if resolution.Unapproved != "" {
+			log.Printf("Warning: %s file '%s' lies within operator window '%s' but is mapped to auto config %v\n", prod.Name, productName, resolution.Unapproved, matchingConfig)
+			unapprovedCount++
+		}
+		if resolution.OnBoundary {
+			log.Printf("%s file '%s' lies exactly on a config interval boundary, mapped to config %v\n", prod.Name, productName, matchingConfig)
+		}
+
+		// 4. Add key file path w/ value config file
+		result[productPath] = matchingConfig
+	}
+
+	if policy.Unapproved == UnapprovedError && unapprovedCount > 0 {
+		log.Fatalf("Error: %d %s file(s) within operator windows are mapped to auto configs", unapprovedCount, prod.Name)
+	}
+
+	return result
+}
@@ -0,0 +1,292 @@
+package mapping
+
+import (
+	"slices"
+	"time"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/config_interval"
+)
+
+// Fallbacks for files outside every config interval
+const (
+	// The config whose interval ended most recently before the file
+	FallbackPreceding = "preceding"
+	// The config whose interval starts soonest after the file
+	FallbackFollowing = "following"
+	// A designated default config
+	FallbackDefault = "default"
+)
+
+// Fallbacks lists the supported fallbacks
+var Fallbacks = []string{FallbackPreceding, FallbackFollowing, FallbackDefault}
+
+// The kind reported for the designated default config
+const ConfigKindDefault = "default"
+
+// Handling of files mapped to auto configs that started longer ago than their maximum validity
+const (
+	// The files are mapped, but flagged as stale
+	StaleFlag = "flag"
+	// The files are left unmapped, unless a config of another source contains them
+	StaleUnmap = "unmap"
+)
+
+// StalePolicies lists the supported handlings of stale auto configs
+var StalePolicies = []string{StaleFlag, StaleUnmap}
+
+// Handling of files resolved to auto configs within windows requiring operator configs
+const (
+	// The files are mapped, but logged and flagged as unapproved
+	UnapprovedWarn = "warn"
+	// Mapping fails if any file is unapproved
+	UnapprovedError = "error"
+)
+
+// UnapprovedPolicies lists the supported handlings of unapproved files
+var UnapprovedPolicies = []string{UnapprovedWarn, UnapprovedError}
+
+// OperatorWindow is a time window [Start, End) in which files must be mapped to operator-approved configs, e.g. for a
+// deployment. A zero End leaves the window open-ended.
+type OperatorWindow struct {
+	Name  string
+	Start time.Time
+	End   time.Time
+}
+
+func (w OperatorWindow) contains(timestamp time.Time) bool {
+	return !timestamp.Before(w.Start) && (w.End.IsZero() || timestamp.Before(w.End))
+}
+
+// Policy holds a site's rules for resolving the timestamps of files to configs. The zero value looks configs up at the
+// timestamps as recorded.
+type Policy struct {
+	Clock ClockCorrection
+	// Files stamped up to this long before an interval starts are snapped to it, e.g. when RangeSeries files are
+	// stamped a few seconds before the auto config created at the same restart
+	BoundaryGrace time.Duration
+	// How files outside every config interval are mapped, not at all if empty
+	Fallback string
+	// The config used by FallbackDefault
+	DefaultConfig string
+	// How long after it starts an auto config stays valid, indefinitely if zero. The latest auto config otherwise
+	// lasts until now, even if the site stopped writing configs long ago.
+	MaxAutoValidity time.Duration
+	// How files mapped to auto configs past their validity are handled, StaleFlag if empty
+	Stale string
+	// How much older the newest auto config may be than the newest file before the site is warned about, never if zero
+	StaleAutoWarning time.Duration
+	// Windows in which files must not resolve to auto configs, and how files that do are handled, UnapprovedWarn if
+	// empty
+	OperatorWindows []OperatorWindow
+	Unapproved      string
+}
+
+// Resolution is the config a file's timestamp resolved to, and how it was found
+type Resolution struct {
+	// The timestamp as parsed from the file name, and as corrected for the site's clock
+	RawTime time.Time
+	Time    time.Time
+	// The time the config was looked up at, later than Time if the file was snapped to the start of an interval
+	LookupTime time.Time
+	Snapped    bool
+	// Whether Time lies exactly on the start or end of any interval
+	OnBoundary bool
+	// The intervals containing LookupTime in order of precedence. The first is the matching config.
+	Candidates []Candidate
+	// Whether the file matched an auto config past its maximum validity. If the policy unmaps stale files, such configs
+	// are moved from the candidates to StaleCandidates, and the file is only stale if no candidate is left.
+	Stale           bool
+	StaleCandidates []Candidate
+	// The name of the operator window containing the file if it resolved to an auto config there
+	Unapproved string
+	// The fallback used if there are no candidates, and the config it chose
+	Fallback         string
+	FallbackInterval config_interval.ConfigInterval
+	FallbackKind     string
+}
+
+// Config returns the matching or fallback config, or an empty string if there is none
+func (r Resolution) Config() string {
+	timeInterval, _, _ := r.Match()
+	return timeInterval.Config
+}
+
+// Match returns the interval of the matching or fallback config and the name of its source
+func (r Resolution) Match() (config_interval.ConfigInterval, string, bool) {
+	if len(r.Candidates) > 0 {
+		return r.Candidates[0].Interval, r.Candidates[0].Kind, true
+	}
+	if r.Fallback != "" {
+		return r.FallbackInterval, r.FallbackKind, true
+	}
+	return config_interval.ConfigInterval{}, "", false
+}
+
+// Resolve corrects a timestamp parsed from a file name and looks up its config according to the policy
+func (p Precedence) Resolve(rawTime time.Time, policy Policy) Resolution {
+	productTime := policy.Clock.Apply(rawTime)
+	res := Resolution{
+		RawTime:    rawTime,
+		Time:       productTime,
+		LookupTime: productTime,
+		OnBoundary: p.onBoundary(productTime),
+	}
+
+	// A file just before the start of an interval is snapped to it, unless that wouldn't change its config
+	if start, ok := p.nextStart(productTime, policy.BoundaryGrace); ok && p.Config(start) != p.Config(productTime) {
+		res.LookupTime, res.Snapped = start, true
+	}
+
+	res.Candidates = p.Candidates(res.LookupTime)
+	if len(res.Candidates) > 0 && policy.MaxAutoValidity > 0 {
+		if policy.Stale == StaleUnmap {
+			res.Candidates = slices.DeleteFunc(res.Candidates, func(candidate Candidate) bool {
+				stale := p.isStale(candidate, res.LookupTime, policy.MaxAutoValidity)
+				if stale {
+					res.StaleCandidates = append(res.StaleCandidates, candidate)
+				}
+				return stale
+			})
+			// The file is only stale if it was left unmapped, not if a config of another source still contains it
+			res.Stale = len(res.StaleCandidates) > 0 && len(res.Candidates) == 0
+		} else {
+			res.Stale = p.isStale(res.Candidates[0], res.LookupTime, policy.MaxAutoValidity)
+		}
+	}
+	// Files unmapped as stale are not mapped by a fallback either, which would choose an even older config, nor are
+	// files within the intervals of excluded configs
+	if len(res.Candidates) == 0 && !res.Stale && !p.excluded(res.LookupTime) {
+		p.applyFallback(&res, policy)
+	}
+
+	// Auto configs are not approved within operator windows, whether they were matched or chosen by a fallback
+	if _, kind, ok := res.Match(); ok {
+		if source, _ := p.Source(kind); source.Scheme == SchemeAuto {
+			for _, window := range policy.OperatorWindows {
+				if window.contains(res.LookupTime) {
+					res.Unapproved = window.Name
+					break
+				}
+			}
+		}
+	}
+	return res
+}
+
+// ResolveTime looks up the config in effect at a timestamp that is already in true time, e.g. one given by the user,
+// according to the policy without its clock correction
+func (p Precedence) ResolveTime(timestamp time.Time, policy Policy) Resolution {
+	policy.Clock = ClockCorrection{}
+	return p.Resolve(timestamp, policy)
+}
+
+// applyFallback maps a file outside every interval according to the policy's fallback
+func (p Precedence) applyFallback(res *Resolution, policy Policy) {
+	var fallback Candidate
+	var ok bool
+
+	switch policy.Fallback {
+	case FallbackPreceding:
+		fallback, ok = p.nearest(func(timeInterval config_interval.ConfigInterval) (time.Duration, bool) {
+			return res.LookupTime.Sub(timeInterval.End), !timeInterval.End.After(res.LookupTime)
+		})
+	case FallbackFollowing:
+		fallback, ok = p.nearest(func(timeInterval config_interval.ConfigInterval) (time.Duration, bool) {
+			return timeInterval.Start.Sub(res.LookupTime), timeInterval.Start.After(res.LookupTime)
+		})
+	case FallbackDefault:
+		fallback = Candidate{Interval: config_interval.ConfigInterval{Config: policy.DefaultConfig}, Kind: ConfigKindDefault}
+		ok = policy.DefaultConfig != ""
+	}
+
+	if ok {
+		res.Fallback, res.FallbackInterval, res.FallbackKind = policy.Fallback, fallback.Interval, fallback.Kind
+	}
+}
+
+// excluded reports whether the timestamp lies within the interval of an excluded config
+func (p Precedence) excluded(timestamp time.Time) bool {
+	for _, source := range p {
+		for _, timeInterval := range source.Excluded {
+			if timeInterval.ContainsTime(timestamp) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isStale reports whether the candidate is an auto config that started more than maxValidity before the timestamp
+func (p Precedence) isStale(candidate Candidate, timestamp time.Time, maxValidity time.Duration) bool {
+	source, _ := p.Source(candidate.Kind)
+	return source.Scheme == SchemeAuto && timestamp.Sub(candidate.Interval.Start) > maxValidity
+}
+
+// StaleAutoConfigs returns the newest config of each auto source that started more than threshold before the
+// timestamp, typically that of the newest file of the site
+func (p Precedence) StaleAutoConfigs(timestamp time.Time, threshold time.Duration) []Candidate {
+	var res []Candidate
+
+	for _, source := range p {
+		n := len(source.Intervals)
+		if source.Scheme != SchemeAuto || n == 0 {
+			continue
+		}
+
+		newest := source.Intervals[n-1]
+		if timestamp.Sub(newest.Start) > threshold {
+			res = append(res, Candidate{Interval: newest, Kind: source.Name})
+		}
+	}
+
+	return res
+}
+
+// nearest returns the interval at the smallest distance, preferring the sources with higher precedence on ties.
+// Intervals for which distance returns false are not considered.
+func (p Precedence) nearest(distance func(config_interval.ConfigInterval) (time.Duration, bool)) (Candidate, bool) {
+	var res Candidate
+	var minDistance time.Duration
+	found := false
+
+	for _, source := range p {
+		for _, timeInterval := range source.Intervals {
+			d, ok := distance(timeInterval)
+			if ok && (!found || d < minDistance) {
+				res, minDistance, found = Candidate{Interval: timeInterval, Kind: source.Name}, d, true
+			}
+		}
+	}
+
+	return res, found
+}
+
+// nextStart returns the earliest interval start in (timestamp, timestamp + grace]
+func (p Precedence) nextStart(timestamp time.Time, grace time.Duration) (time.Time, bool) {
+	var res time.Time
+	if grace <= 0 {
+		return res, false
+	}
+
+	for _, source := range p {
+		for _, timeInterval := range source.Intervals {
+			start := timeInterval.Start
+			if start.After(timestamp) && !start.After(timestamp.Add(grace)) && (res.IsZero() || start.Before(res)) {
+				res = start
+			}
+		}
+	}
+
+	return res, !res.IsZero()
+}
+
+func (p Precedence) onBoundary(timestamp time.Time) bool {
+	for _, source := range p {
+		for _, timeInterval := range source.Intervals {
+			if timestamp.Equal(timeInterval.Start) || timestamp.Equal(timeInterval.End) {
+				return true
+			}
+		}
+	}
+	return false
+}
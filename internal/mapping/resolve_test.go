@@ -0,0 +1,301 @@
+package mapping
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/config_interval"
+)
+
+func TestResolve(t *testing.T) {
+	// Arrange
+	restart := time.Date(2023, 5, 20, 12, 0, 0, 0, time.UTC)
+	precedence := Precedence{
+		{Name: ConfigKindOperator, Scheme: SchemeOperator, Intervals: []config_interval.ConfigInterval{
+			{Start: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2023, 6, 10, 0, 0, 0, 0, time.UTC), Config: "20230601T000000Z-20230610T000000Z"},
+		}},
+		{Name: ConfigKindAuto, Scheme: SchemeAuto, Intervals: []config_interval.ConfigInterval{
+			{Start: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), End: restart, Config: "20230501T000000Z"},
+			{Start: restart, End: time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC), Config: "20230520T120000Z"},
+			{Start: time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC), End: time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC), Config: "20230605T000000Z"},
+		}},
+	}
+
+	// Define test cases
+	tests := []struct {
+		name           string
+		timestamp      time.Time
+		grace          time.Duration
+		wantConfig     string
+		wantSnapped    bool
+		wantOnBoundary bool
+	}{
+		{"Within grace", restart.Add(-5 * time.Second), 10 * time.Second, "20230520T120000Z", true, false},
+		{"At the end of the grace", restart.Add(-10 * time.Second), 10 * time.Second, "20230520T120000Z", true, false},
+		{"Beyond grace", restart.Add(-11 * time.Second), 10 * time.Second, "20230501T000000Z", false, false},
+		{"No grace", restart.Add(-5 * time.Second), 0, "20230501T000000Z", false, false},
+		{"Before the first config", time.Date(2023, 4, 30, 23, 59, 55, 0, time.UTC), 10 * time.Second, "20230501T000000Z", true, false},
+		{"On boundary", restart, 10 * time.Second, "20230520T120000Z", false, true},
+		{"Same config after the start", time.Date(2023, 6, 4, 23, 59, 55, 0, time.UTC), 10 * time.Second, "20230601T000000Z-20230610T000000Z", false, false},
+		{"Snapped to operator config", time.Date(2023, 5, 31, 23, 59, 55, 0, time.UTC), 10 * time.Second, "20230601T000000Z-20230610T000000Z", true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute test
+			got := precedence.Resolve(tt.timestamp, Policy{BoundaryGrace: tt.grace})
+
+			// Assert results
+			if got.Config() != tt.wantConfig || got.Snapped != tt.wantSnapped || got.OnBoundary != tt.wantOnBoundary {
+				t.Errorf("Resolve() = %v (snapped %v, on boundary %v), want %v (snapped %v, on boundary %v)", got.Config(), got.Snapped, got.OnBoundary, tt.wantConfig, tt.wantSnapped, tt.wantOnBoundary)
+			}
+			if !got.Time.Equal(tt.timestamp) {
+				t.Errorf("Resolve() time = %v, want %v", got.Time, tt.timestamp)
+			}
+		})
+	}
+}
+
+func TestResolveFallback(t *testing.T) {
+	// Arrange
+	precedence := Precedence{
+		{Name: ConfigKindOperator, Scheme: SchemeOperator, Intervals: []config_interval.ConfigInterval{
+			{Start: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2023, 3, 10, 0, 0, 0, 0, time.UTC), Config: "20230301T000000Z-20230310T000000Z"},
+		}},
+		{Name: ConfigKindAuto, Scheme: SchemeAuto, Intervals: []config_interval.ConfigInterval{
+			{Start: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), Config: "20230501T000000Z"},
+		}},
+	}
+
+	// Define test cases
+	tests := []struct {
+		name         string
+		timestamp    time.Time
+		policy       Policy
+		wantConfig   string
+		wantKind     string
+		wantFallback string
+	}{
+		{"No fallback", time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), Policy{}, "", "", ""},
+		{"Preceding", time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC), Policy{Fallback: FallbackPreceding}, "20230301T000000Z-20230310T000000Z", ConfigKindOperator, FallbackPreceding},
+		{"Preceding, before the first config", time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), Policy{Fallback: FallbackPreceding}, "", "", ""},
+		{"Following", time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC), Policy{Fallback: FallbackFollowing}, "20230501T000000Z", ConfigKindAuto, FallbackFollowing},
+		{"Following, before the first config", time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), Policy{Fallback: FallbackFollowing}, "20230301T000000Z-20230310T000000Z", ConfigKindOperator, FallbackFollowing},
+		{"Default", time.Date(2023, 2, 1, 0, 0, 0, 0, time.UTC), Policy{Fallback: FallbackDefault, DefaultConfig: "/configs/default"}, "/configs/default", ConfigKindDefault, FallbackDefault},
+		{"Within an interval", time.Date(2023, 5, 2, 0, 0, 0, 0, time.UTC), Policy{Fallback: FallbackDefault, DefaultConfig: "/configs/default"}, "20230501T000000Z", ConfigKindAuto, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute test
+			got := precedence.Resolve(tt.timestamp, tt.policy)
+
+			// Assert results
+			_, gotKind, _ := got.Match()
+			if got.Config() != tt.wantConfig || gotKind != tt.wantKind || got.Fallback != tt.wantFallback {
+				t.Errorf("Resolve() = %v (%v, fallback %v), want %v (%v, fallback %v)", got.Config(), gotKind, got.Fallback, tt.wantConfig, tt.wantKind, tt.wantFallback)
+			}
+		})
+	}
+}
+
+func TestResolveTime(t *testing.T) {
+	// Arrange
+	location, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		t.Fatalf("Failed to load timezone: %v", err)
+	}
+	precedence := Precedence{
+		{Name: ConfigKindOperator, Scheme: SchemeOperator, Intervals: []config_interval.ConfigInterval{
+			{Start: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2023, 3, 10, 0, 0, 0, 0, time.UTC), Config: "20230301T000000Z-20230310T000000Z"},
+		}},
+		{Name: ConfigKindAuto, Scheme: SchemeAuto, Intervals: []config_interval.ConfigInterval{
+			{Start: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), Config: "20230501T000000Z"},
+		}},
+	}
+	policy := Policy{Clock: ClockCorrection{Location: location}, Fallback: FallbackPreceding}
+
+	// Define test cases
+	tests := []struct {
+		name         string
+		timestamp    time.Time
+		wantConfig   string
+		wantFallback string
+	}{
+		{"Clock correction is not applied", time.Date(2023, 5, 31, 20, 0, 0, 0, time.UTC), "20230501T000000Z", ""},
+		{"Fallback is applied", time.Date(2023, 4, 1, 0, 0, 0, 0, time.UTC), "20230301T000000Z-20230310T000000Z", FallbackPreceding},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute test
+			got := precedence.ResolveTime(tt.timestamp, policy)
+
+			// Assert results
+			if got.Config() != tt.wantConfig || got.Fallback != tt.wantFallback || !got.Time.Equal(tt.timestamp) {
+				t.Errorf("ResolveTime() = %v at %v (fallback %v), want %v at %v (fallback %v)", got.Config(), got.Time, got.Fallback, tt.wantConfig, tt.timestamp, tt.wantFallback)
+			}
+		})
+	}
+}
+
+func TestResolveStale(t *testing.T) {
+	// Arrange
+	precedence := Precedence{
+		{Name: ConfigKindOperator, Scheme: SchemeOperator, Intervals: []config_interval.ConfigInterval{
+			{Start: time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2023, 9, 10, 0, 0, 0, 0, time.UTC), Config: "20230901T000000Z-20230910T000000Z"},
+		}},
+		{Name: ConfigKindAuto, Scheme: SchemeAuto, Intervals: []config_interval.ConfigInterval{
+			{Start: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), Config: "20230501T000000Z"},
+		}},
+	}
+	maxValidity := 30 * 24 * time.Hour
+
+	// Define test cases
+	tests := []struct {
+		name       string
+		timestamp  time.Time
+		policy     Policy
+		wantConfig string
+		wantStale  bool
+	}{
+		{"Within validity", time.Date(2023, 5, 20, 0, 0, 0, 0, time.UTC), Policy{MaxAutoValidity: maxValidity}, "20230501T000000Z", false},
+		{"No maximum validity", time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC), Policy{}, "20230501T000000Z", false},
+		{"Flagged", time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC), Policy{MaxAutoValidity: maxValidity}, "20230501T000000Z", true},
+		{"Unmapped", time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC), Policy{MaxAutoValidity: maxValidity, Stale: StaleUnmap}, "", true},
+		{"Unmapped without fallback", time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC), Policy{MaxAutoValidity: maxValidity, Stale: StaleUnmap, Fallback: FallbackFollowing}, "", true},
+		{"Operator configs never stale", time.Date(2023, 9, 2, 0, 0, 0, 0, time.UTC), Policy{MaxAutoValidity: maxValidity, Stale: StaleUnmap}, "20230901T000000Z-20230910T000000Z", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute test
+			got := precedence.Resolve(tt.timestamp, tt.policy)
+
+			// Assert results
+			if got.Config() != tt.wantConfig || got.Stale != tt.wantStale {
+				t.Errorf("Resolve() = %v (stale %v), want %v (stale %v)", got.Config(), got.Stale, tt.wantConfig, tt.wantStale)
+			}
+		})
+	}
+}
+
+func TestResolveStaleCandidates(t *testing.T) {
+	// Arrange
+	operator := Source{Name: ConfigKindOperator, Scheme: SchemeOperator, Intervals: []config_interval.ConfigInterval{
+		{Start: time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2023, 9, 1, 0, 0, 0, 0, time.UTC), Config: "20230701T000000Z-20230901T000000Z"},
+	}}
+	auto := Source{Name: ConfigKindAuto, Scheme: SchemeAuto, Intervals: []config_interval.ConfigInterval{
+		{Start: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), Config: "20230501T000000Z"},
+	}}
+	policy := Policy{MaxAutoValidity: 30 * 24 * time.Hour, Stale: StaleUnmap, Fallback: FallbackPreceding}
+	timestamp := time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC)
+
+	// Define test cases
+	tests := []struct {
+		name       string
+		precedence Precedence
+		wantConfig string
+		wantStale  bool
+	}{
+		{"Operator above stale auto", Precedence{operator, auto}, "20230701T000000Z-20230901T000000Z", false},
+		{"Stale auto above operator", Precedence{auto, operator}, "20230701T000000Z-20230901T000000Z", false},
+		{"Stale auto only", Precedence{auto}, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute test
+			got := tt.precedence.Resolve(timestamp, policy)
+
+			// Assert results
+			if got.Config() != tt.wantConfig || got.Stale != tt.wantStale || got.Fallback != "" {
+				t.Errorf("Resolve() = %v (stale %v, fallback %q), want %v (stale %v)", got.Config(), got.Stale, got.Fallback, tt.wantConfig, tt.wantStale)
+			}
+			if len(got.StaleCandidates) != 1 || got.StaleCandidates[0].Interval.Config != "20230501T000000Z" {
+				t.Errorf("Resolve() stale candidates = %v, want the auto config", got.StaleCandidates)
+			}
+		})
+	}
+}
+
+func TestStaleAutoConfigs(t *testing.T) {
+	// Arrange
+	precedence := Precedence{
+		{Name: ConfigKindOperator, Scheme: SchemeOperator, Intervals: []config_interval.ConfigInterval{
+			{Start: time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC), Config: "20230101T000000Z-20230110T000000Z"},
+		}},
+		{Name: ConfigKindAuto, Scheme: SchemeAuto, Intervals: []config_interval.ConfigInterval{
+			{Start: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), Config: "20230301T000000Z"},
+			{Start: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), Config: "20230501T000000Z"},
+		}},
+	}
+
+	// Define test cases
+	tests := []struct {
+		name      string
+		timestamp time.Time
+		want      []string
+	}{
+		{"Recent auto config", time.Date(2023, 5, 3, 0, 0, 0, 0, time.UTC), nil},
+		{"Stale auto config", time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC), []string{"20230501T000000Z"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute test
+			got := precedence.StaleAutoConfigs(tt.timestamp, 7*24*time.Hour)
+
+			// Assert results
+			var gotConfigs []string
+			for _, candidate := range got {
+				gotConfigs = append(gotConfigs, candidate.Interval.Config)
+			}
+			if !reflect.DeepEqual(gotConfigs, tt.want) {
+				t.Errorf("StaleAutoConfigs() = %v, want %v", gotConfigs, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveOperatorWindows(t *testing.T) {
+	// Arrange
+	precedence := Precedence{
+		{Name: ConfigKindOperator, Scheme: SchemeOperator, Intervals: []config_interval.ConfigInterval{
+			{Start: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2023, 6, 10, 0, 0, 0, 0, time.UTC), Config: "20230601T000000Z-20230610T000000Z"},
+		}},
+		{Name: ConfigKindAuto, Scheme: SchemeAuto, Intervals: []config_interval.ConfigInterval{
+			{Start: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2023, 8, 1, 0, 0, 0, 0, time.UTC), Config: "20230501T000000Z"},
+		}},
+	}
+	policy := Policy{OperatorWindows: []OperatorWindow{
+		{Name: "deployment", Start: time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC), End: time.Date(2023, 7, 1, 0, 0, 0, 0, time.UTC)},
+	}}
+
+	// Define test cases
+	tests := []struct {
+		name           string
+		timestamp      time.Time
+		policy         Policy
+		wantConfig     string
+		wantUnapproved string
+	}{
+		{"Operator config within window", time.Date(2023, 6, 5, 0, 0, 0, 0, time.UTC), policy, "20230601T000000Z-20230610T000000Z", ""},
+		{"Auto config within window", time.Date(2023, 6, 15, 0, 0, 0, 0, time.UTC), policy, "20230501T000000Z", "deployment"},
+		{"Auto config outside window", time.Date(2023, 7, 15, 0, 0, 0, 0, time.UTC), policy, "20230501T000000Z", ""},
+		{"No windows", time.Date(2023, 6, 15, 0, 0, 0, 0, time.UTC), Policy{}, "20230501T000000Z", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute test
+			got := precedence.Resolve(tt.timestamp, tt.policy)
+
+			// Assert results
+			if got.Config() != tt.wantConfig || got.Unapproved != tt.wantUnapproved {
+				t.Errorf("Resolve() = %v (unapproved %q), want %v (unapproved %q)", got.Config(), got.Unapproved, tt.wantConfig, tt.wantUnapproved)
+			}
+		})
+	}
+}
@@ -0,0 +1,325 @@
+package materialize
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/read"
+)
+
+const (
+	ModeSymlink  = "symlink"
+	ModeHardlink = "hardlink"
+	ModeCopy     = "copy"
+)
+
+var Modes = []string{ModeSymlink, ModeHardlink, ModeCopy}
+
+// Name of the link to (or copy of) the config inside each config's directory
+const ConfigLinkName = "Config"
+
+// Top-level directories named like auto or operator configs are managed, as are those holding a `Config` entry, e.g.
+// the directories of manifest configs. Other files in the output directory are left alone.
+var configDirRegex = regexp.MustCompile(`^\d{8}T\d{6}Z(-(\d{8}T\d{6}Z|present))?$`)
+
+const (
+	OpCreate  = "create"
+	OpReplace = "replace"
+	OpRemove  = "remove"
+)
+
+// Entry is a file of the materialized tree, created from Target using Mode
+type Entry struct {
+	Path   string
+	Target string
+	Mode   string
+}
+
+// Action is a change needed to bring the materialized tree up to date
+type Action struct {
+	Op string
+	Entry
+}
+
+func (a Action) String() string {
+	if a.Op == OpRemove {
+		return fmt.Sprintf("%-7s %s", a.Op, a.Path)
+	}
+	return fmt.Sprintf("%-7s %s -> %s (%s)", a.Op, a.Path, a.Target, a.Mode)
+}
+
+// Entries lays out the mapped files as `<out>/<config-name>/<path within site>`, e.g.
+// `<out>/20230501T000000Z/RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610.rs`, next to a `Config` entry for the
+// config. The config is symlinked, unless mode is copy, in which case its files are copied.
+func Entries(site read.Site, fileToConfig map[string]string, out string, mode string) ([]Entry, error) {
+	if site.IsSnapshot() {
+		return nil, fmt.Errorf("cannot materialize files of snapshot site %s", site.Root)
+	}
+
+	root, err := filepath.Abs(site.Root)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []Entry
+	configs := make(map[string]bool)
+	configDirs := make(map[string]string)
+
+	for file, config := range fileToConfig {
+		if config == "" {
+			log.Printf("Skipping file '%s': no matching config\n", file)
+			continue
+		}
+		if strings.Contains(file, read.ArchiveMemberSeparator) {
+			log.Printf("Skipping file '%s': archive members cannot be materialized\n", file)
+			continue
+		}
+
+		rel, err := site.Rel(file)
+		if err != nil {
+			return nil, err
+		}
+
+		// Configs of different sources may share a name, e.g. a manifest config named like an auto config
+		dir := filepath.Base(config)
+		if other, ok := configDirs[dir]; ok && other != config {
+			return nil, fmt.Errorf("configs %v and %v would both be materialized to %v", min(config, other), max(config, other), filepath.Join(out, dir))
+		}
+		configDirs[dir] = config
+
+		res = append(res, Entry{
+			Path:   filepath.Join(out, filepath.Base(config), filepath.FromSlash(rel)),
+			Target: filepath.Join(root, filepath.FromSlash(rel)),
+			Mode:   mode,
+		})
+		configs[config] = true
+	}
+
+	for config := range configs {
+		rel, err := site.Rel(config)
+		if err != nil {
+			return nil, err
+		}
+		configEntry := filepath.Join(out, filepath.Base(config), ConfigLinkName)
+
+		if mode != ModeCopy {
+			res = append(res, Entry{Path: configEntry, Target: filepath.Join(root, filepath.FromSlash(rel)), Mode: ModeSymlink})
+			continue
+		}
+
+		err = fs.WalkDir(site.FS, rel, func(name string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+
+			configFile := strings.TrimPrefix(name, rel+"/")
+			res = append(res, Entry{
+				Path:   filepath.Join(configEntry, filepath.FromSlash(configFile)),
+				Target: filepath.Join(root, filepath.FromSlash(name)),
+				Mode:   ModeCopy,
+			})
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("error reading config %v: %v", config, err)
+		}
+	}
+
+	slices.SortFunc(res, func(a, b Entry) int { return strings.Compare(a.Path, b.Path) })
+	return res, nil
+}
+
+// RewriteLinks rewrites the targets of the symlinks among the entries, e.g. to the path the site is mounted at on the
+// host the tree is used on. If relative is set, targets are instead made relative to the directory of their link, so
+// that the tree keeps working when moved along with the site.
+func RewriteLinks(entries []Entry, rewrite func(string) string, relative bool) ([]Entry, error) {
+	res := slices.Clone(entries)
+
+	for i, entry := range res {
+		if entry.Mode != ModeSymlink {
+			continue
+		}
+		if !relative {
+			res[i].Target = rewrite(entry.Target)
+			continue
+		}
+
+		linkDir, err := filepath.Abs(filepath.Dir(entry.Path))
+		if err != nil {
+			return nil, err
+		}
+		res[i].Target, err = filepath.Rel(linkDir, entry.Target)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// Plan compares the entries with the tree in out. Files in the managed config directories that are not entries are
+// removed first, then entries that are missing or differ are (re)created.
+func Plan(entries []Entry, out string) ([]Action, error) {
+	wanted := make(map[string]bool)
+	for _, entry := range entries {
+		wanted[entry.Path] = true
+	}
+
+	var res []Action
+	managed := make(map[string]bool)
+	err := filepath.WalkDir(out, func(name string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && name == out {
+			return filepath.SkipDir
+		} else if err != nil {
+			return err
+		}
+
+		rel, _ := filepath.Rel(out, name)
+		topLevel := strings.Split(filepath.ToSlash(rel), "/")[0]
+		if name != out && rel == topLevel && d.IsDir() {
+			_, err := os.Lstat(filepath.Join(name, ConfigLinkName))
+			managed[topLevel] = configDirRegex.MatchString(topLevel) || err == nil
+		}
+		if name != out && !managed[topLevel] {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+
+		if !d.IsDir() && !wanted[name] {
+			res = append(res, Action{Op: OpRemove, Entry: Entry{Path: name}})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	removed := len(res)
+
+	for _, entry := range entries {
+		// An entry below a removed file, e.g. a copied config replacing a config symlink, will be created afresh
+		if slices.ContainsFunc(res[:removed], func(a Action) bool { return strings.HasPrefix(entry.Path, a.Path+string(filepath.Separator)) }) {
+			res = append(res, Action{Op: OpCreate, Entry: entry})
+			continue
+		}
+
+		info, err := os.Lstat(entry.Path)
+		if errors.Is(err, fs.ErrNotExist) {
+			res = append(res, Action{Op: OpCreate, Entry: entry})
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if !upToDate(entry, info) {
+			res = append(res, Action{Op: OpReplace, Entry: entry})
+		}
+	}
+
+	return res, nil
+}
+
+// upToDate reports whether the existing file at the entry's path was created from its target
+func upToDate(entry Entry, info fs.FileInfo) bool {
+	switch entry.Mode {
+	case ModeSymlink:
+		if info.Mode()&fs.ModeSymlink == 0 {
+			return false
+		}
+		target, err := os.Readlink(entry.Path)
+		return err == nil && target == entry.Target
+	case ModeHardlink:
+		targetInfo, err := os.Stat(entry.Target)
+		return err == nil && os.SameFile(info, targetInfo)
+	case ModeCopy:
+		targetInfo, err := os.Stat(entry.Target)
+		return err == nil && info.Mode().IsRegular() && info.Size() == targetInfo.Size() && info.ModTime().Equal(targetInfo.ModTime())
+	}
+
+	return false
+}
+
+// Apply performs the actions. Directories left empty by removals are removed up to out.
+func Apply(actions []Action, out string) error {
+	for _, action := range actions {
+		if action.Op == OpRemove || action.Op == OpReplace {
+			// A replaced directory may already have been emptied and removed by earlier removals
+			if err := os.Remove(action.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		}
+
+		if action.Op == OpRemove {
+			removeEmptyParents(action.Path, out)
+			continue
+		}
+
+		if err := os.MkdirAll(filepath.Dir(action.Path), 0755); err != nil {
+			return err
+		}
+		if err := create(action.Entry); err != nil {
+			return fmt.Errorf("error creating %v: %v", action.Path, err)
+		}
+	}
+
+	return nil
+}
+
+func create(entry Entry) error {
+	switch entry.Mode {
+	case ModeSymlink:
+		return os.Symlink(entry.Target, entry.Path)
+	case ModeHardlink:
+		return os.Link(entry.Target, entry.Path)
+	case ModeCopy:
+		return copyFile(entry.Target, entry.Path)
+	}
+
+	return fmt.Errorf("unknown mode '%s'", entry.Mode)
+}
+
+// copyFile copies the file, keeping its modification time so that re-runs can tell the copy is up to date
+func copyFile(src string, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	info, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	outFile, err := os.OpenFile(dst, os.O_CREATE|os.O_EXCL|os.O_WRONLY, info.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(outFile, in); err != nil {
+		outFile.Close()
+		return err
+	}
+	if err := outFile.Close(); err != nil {
+		return err
+	}
+
+	return os.Chtimes(dst, info.ModTime(), info.ModTime())
+}
+
+func removeEmptyParents(name string, out string) {
+	out = filepath.Clean(out)
+	for dir := filepath.Dir(name); dir != out && strings.HasPrefix(dir, out); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			return
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package materialize
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/read"
+)
+
+func writeFiles(t *testing.T, root string, names ...string) {
+	t.Helper()
+
+	for _, name := range names {
+		path := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(name), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func countOps(actions []Action) map[string]int {
+	res := make(map[string]int)
+	for _, action := range actions {
+		res[action.Op]++
+	}
+	return res
+}
+
+func TestMaterialize(t *testing.T) {
+	// Arrange
+	siteDir := filepath.Join(t.TempDir(), "MGS1")
+	out := filepath.Join(t.TempDir(), "out")
+	writeFiles(t, siteDir,
+		"Config_Auto/20230501T000000Z/Header.txt",
+		"RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610.rs",
+		"RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_073610.rs",
+	)
+	writeFiles(t, out, "notes.txt", "20230401T000000Z/RangeSeries/2023/04/01/Rng_mgs1_2023_04_01_000000.rs",
+		"campaign/Config/Header.txt", "campaign/RangeSeries/2023/04/01/Rng_mgs1_2023_04_01_000000.rs")
+	site := read.NewSite(os.DirFS(siteDir), siteDir)
+
+	config := filepath.Join(siteDir, "Config_Auto/20230501T000000Z")
+	fileToConfig := map[string]string{
+		filepath.Join(siteDir, "RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610.rs"):         config,
+		filepath.Join(siteDir, "RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_073610.rs"):         config,
+		filepath.Join(siteDir, "RangeSeries/2023/05/17/17.zip!/Rng_mgs1_2023_05_17_080610.rs"): config,
+		filepath.Join(siteDir, "RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_083610.rs"):         "",
+	}
+
+	// Define test cases, run in order against the same output directory
+	tests := []struct {
+		name    string
+		mode    string
+		wantOps map[string]int
+	}{
+		{"Create symlinks and remove stale files", ModeSymlink, map[string]int{OpCreate: 3, OpRemove: 3}},
+		{"Re-run is a no-op", ModeSymlink, map[string]int{}},
+		{"Switch to copies", ModeCopy, map[string]int{OpReplace: 2, OpRemove: 1, OpCreate: 1}},
+		{"Re-run copies is a no-op", ModeCopy, map[string]int{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute test
+			entries, err := Entries(site, fileToConfig, out, tt.mode)
+			if err != nil {
+				t.Fatalf("Entries() error = %v", err)
+			}
+			actions, err := Plan(entries, out)
+			if err != nil {
+				t.Fatalf("Plan() error = %v", err)
+			}
+			if err := Apply(actions, out); err != nil {
+				t.Fatalf("Apply() error = %v", err)
+			}
+
+			// Assert results
+			if got := countOps(actions); len(got) != len(tt.wantOps) || got[OpCreate] != tt.wantOps[OpCreate] ||
+				got[OpReplace] != tt.wantOps[OpReplace] || got[OpRemove] != tt.wantOps[OpRemove] {
+				t.Errorf("Plan() = %v, want %v", actions, tt.wantOps)
+			}
+		})
+	}
+
+	// Files outside the config directories are left alone, and emptied config directories are removed
+	if _, err := os.Stat(filepath.Join(out, "notes.txt")); err != nil {
+		t.Errorf("unmanaged file was removed: %v", err)
+	}
+	for _, dir := range []string{"20230401T000000Z", "campaign"} {
+		if _, err := os.Stat(filepath.Join(out, dir)); !os.IsNotExist(err) {
+			t.Errorf("stale config directory %v was not removed: %v", dir, err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(out, "20230501T000000Z", ConfigLinkName, "Header.txt"))
+	if err != nil || string(data) != "Config_Auto/20230501T000000Z/Header.txt" {
+		t.Errorf("copied config = %q, %v", data, err)
+	}
+}
+
+func TestRewriteLinks(t *testing.T) {
+	// Arrange
+	entries := []Entry{
+		{Path: "/out/20230501T000000Z/RangeSeries/x.rs", Target: "/archive/MGS1/RangeSeries/x.rs", Mode: ModeSymlink},
+		{Path: "/out/20230501T000000Z/Config/Header.txt", Target: "/archive/MGS1/Config_Auto/20230501T000000Z/Header.txt", Mode: ModeCopy},
+	}
+	rewrite := func(path string) string { return "/mnt" + path }
+
+	// Define test cases
+	tests := []struct {
+		name        string
+		relative    bool
+		wantTargets []string
+	}{
+		{"Rewritten", false, []string{"/mnt/archive/MGS1/RangeSeries/x.rs", "/archive/MGS1/Config_Auto/20230501T000000Z/Header.txt"}},
+		{"Relative to link", true, []string{"../../../archive/MGS1/RangeSeries/x.rs", "/archive/MGS1/Config_Auto/20230501T000000Z/Header.txt"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute test
+			got, err := RewriteLinks(entries, rewrite, tt.relative)
+
+			// Assert results
+			if err != nil {
+				t.Fatalf("RewriteLinks() error = %v", err)
+			}
+			for i, entry := range got {
+				if entry.Target != filepath.FromSlash(tt.wantTargets[i]) {
+					t.Errorf("RewriteLinks() target %d = %v, want %v", i, entry.Target, tt.wantTargets[i])
+				}
+			}
+		})
+	}
+}
+
+func TestEntriesNameCollision(t *testing.T) {
+	// Arrange
+	siteDir := filepath.Join(t.TempDir(), "MGS1")
+	writeFiles(t, siteDir,
+		"Config_Auto/20230501T000000Z/Header.txt",
+		"Configs/20230501T000000Z/Header.txt",
+		"RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610.rs",
+		"RangeSeries/2023/05/18/Rng_mgs1_2023_05_18_070610.rs",
+	)
+	site := read.NewSite(os.DirFS(siteDir), siteDir)
+
+	fileToConfig := map[string]string{
+		filepath.Join(siteDir, "RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610.rs"): filepath.Join(siteDir, "Config_Auto/20230501T000000Z"),
+		filepath.Join(siteDir, "RangeSeries/2023/05/18/Rng_mgs1_2023_05_18_070610.rs"): filepath.Join(siteDir, "Configs/20230501T000000Z"),
+	}
+
+	// Execute test
+	_, err := Entries(site, fileToConfig, t.TempDir(), ModeSymlink)
+
+	// Assert results
+	if err == nil || !strings.Contains(err.Error(), "would both be materialized") {
+		t.Errorf("Entries() error = %v, want name collision", err)
+	}
+}
@@ -0,0 +1,110 @@
+package pathmap
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+)
+
+// Separates the old and new prefix of a rule, e.g. `/my/hfradar/archive=/mnt/archive`
+const ruleSeparator = "="
+
+// Rule replaces the From prefix of paths by To
+type Rule struct {
+	From string
+	To   string
+}
+
+// Rewriter converts paths between the form seen by this host and the form written to and read from output
+type Rewriter struct {
+	rules []Rule
+	// Paths within this directory are written relative to it, if set
+	relativeTo string
+}
+
+// ParseRule parses a rule of the form `/old=/new`
+func ParseRule(str string) (Rule, error) {
+	from, to, ok := strings.Cut(str, ruleSeparator)
+	if !ok || from == "" || to == "" {
+		return Rule{}, fmt.Errorf("invalid path map '%s', expected /old=/new", str)
+	}
+
+	return Rule{From: trimSeparator(from), To: trimSeparator(to)}, nil
+}
+
+// New returns a rewriter applying the rules, first match first. If relativeTo is set, paths within it are made
+// relative to it instead.
+func New(rules []Rule, relativeTo string) Rewriter {
+	return Rewriter{rules: rules, relativeTo: trimSeparator(relativeTo)}
+}
+
+// IsZero reports whether the rewriter leaves all paths unchanged
+func (r Rewriter) IsZero() bool {
+	return len(r.rules) == 0 && r.relativeTo == ""
+}
+
+// Output rewrites a path for output
+func (r Rewriter) Output(path string) string {
+	if r.relativeTo != "" {
+		if rel, ok := cutPrefix(path, r.relativeTo); ok {
+			return rel
+		}
+	}
+
+	for _, rule := range r.rules {
+		if rel, ok := cutPrefix(path, rule.From); ok {
+			return join(rule.To, rel)
+		}
+	}
+
+	return path
+}
+
+// Input rewrites a path read from input back into the form seen by this host, reversing the rules. If relativeTo is
+// set, relative paths are resolved against it.
+func (r Rewriter) Input(path string) string {
+	if r.relativeTo != "" && !filepath.IsAbs(path) {
+		return join(r.relativeTo, filepath.Clean(path))
+	}
+
+	for _, rule := range r.rules {
+		if rel, ok := cutPrefix(path, rule.To); ok {
+			return join(rule.From, rel)
+		}
+	}
+
+	return path
+}
+
+// cutPrefix returns the remainder of path after the directory prefix, or "." if path is the prefix itself
+func cutPrefix(path string, prefix string) (string, bool) {
+	if path == prefix {
+		return ".", true
+	}
+
+	if !strings.HasSuffix(prefix, "/") && !strings.HasSuffix(prefix, string(filepath.Separator)) {
+		prefix += string(filepath.Separator)
+	}
+
+	rel, ok := strings.CutPrefix(path, prefix)
+	return rel, ok && rel != ""
+}
+
+func join(prefix string, rel string) string {
+	if rel == "." {
+		return prefix
+	}
+	if strings.HasSuffix(prefix, string(filepath.Separator)) {
+		return prefix + rel
+	}
+	return prefix + string(filepath.Separator) + rel
+}
+
+// trimSeparator removes trailing separators, keeping a lone root separator
+func trimSeparator(path string) string {
+	trimmed := strings.TrimRight(path, `/\`)
+	if trimmed == "" {
+		return path
+	}
+	return trimmed
+}
@@ -0,0 +1,67 @@
+package pathmap
+
+import "testing"
+
+func TestParseRule(t *testing.T) {
+	// Define test cases
+	tests := []struct {
+		str     string
+		want    Rule
+		wantErr bool
+	}{
+		{"/my/hfradar/archive=/mnt/archive", Rule{From: "/my/hfradar/archive", To: "/mnt/archive"}, false},
+		{"/my/hfradar/archive/=/mnt/archive/", Rule{From: "/my/hfradar/archive", To: "/mnt/archive"}, false},
+		{"/my/hfradar/archive", Rule{}, true},
+		{"=/mnt/archive", Rule{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.str, func(t *testing.T) {
+			got, err := ParseRule(tt.str)
+			if (err != nil) != tt.wantErr || got != tt.want {
+				t.Errorf("ParseRule() = %v, %v, want %v, error %v", got, err, tt.want, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRewriter(t *testing.T) {
+	// Arrange
+	rules := []Rule{
+		{From: "/my/hfradar/archive", To: "/mnt/archive"},
+		{From: "/", To: "/host"},
+	}
+
+	// Define test cases
+	tests := []struct {
+		name       string
+		relativeTo string
+		path       string
+		wantOutput string
+	}{
+		{"Rewritten prefix", "", "/my/hfradar/archive/UCSB/MGS1/Config_Auto/20230501T000000Z", "/mnt/archive/UCSB/MGS1/Config_Auto/20230501T000000Z"},
+		{"Prefix only at directory boundary", "", "/my/hfradar/archive2/x.rs", "/host/my/hfradar/archive2/x.rs"},
+		{"Archive member", "", "/my/hfradar/archive/RangeSeries/2023/05/17.zip!/Rng_mgs1_2023_05_17_070610.rs", "/mnt/archive/RangeSeries/2023/05/17.zip!/Rng_mgs1_2023_05_17_070610.rs"},
+		{"Relative to site", "/my/hfradar/archive/UCSB/MGS1/", "/my/hfradar/archive/UCSB/MGS1/RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610.rs", "RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610.rs"},
+		{"Outside site falls back to rules", "/my/hfradar/archive/UCSB/MGS1", "/my/hfradar/archive/UCSB/SCI1/x.rs", "/mnt/archive/UCSB/SCI1/x.rs"},
+		{"Relative input is kept", "", "RangeSeries/x.rs", "RangeSeries/x.rs"},
+		{"Site directory itself", "/my/hfradar/archive/UCSB/MGS1", "/my/hfradar/archive/UCSB/MGS1", "."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rewriter := New(rules, tt.relativeTo)
+
+			// Execute test
+			got := rewriter.Output(tt.path)
+
+			// Assert results
+			if got != tt.wantOutput {
+				t.Errorf("Output() = %v, want %v", got, tt.wantOutput)
+			}
+			if back := rewriter.Input(got); back != tt.path {
+				t.Errorf("Input(%v) = %v, want %v", got, back, tt.path)
+			}
+		})
+	}
+}
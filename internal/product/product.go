@@ -0,0 +1,162 @@
+package product
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Product describes a kind of SeaSonde data file that is mapped to configs by the timestamp in its name
+type Product struct {
+	Name string `json:"name"`
+	// Directory holding the product's files, relative to the site directory
+	Dir string `json:"dir"`
+	// Matched against the full path of files found when scanning Dir
+	FilePathPattern string `json:"file_path_pattern"`
+	// Matched against the name of files given explicitly or found inside archives
+	FileNamePattern string `json:"file_name_pattern"`
+	// Extracts the timestamp from the file name
+	TimestampPattern string `json:"timestamp_pattern"`
+	// Go reference time layout of the extracted timestamp
+	TimestampLayout string `json:"timestamp_layout"`
+}
+
+const RangeSeriesName = "RangeSeries"
+
+var RangeSeries = Product{
+	Name:             RangeSeriesName,
+	Dir:              "RangeSeries",
+	FilePathPattern:  `\d{4}\/\d{2}\/\d{2}/.*\.rs(\.gz|\.bz2)?$`,
+	FileNamePattern:  `\.rs(\.gz|\.bz2)?$`,
+	TimestampPattern: `\d{4}_\d{2}_\d{2}_\d{6}`,
+	TimestampLayout:  "2006_01_02_150405",
+}
+
+// Builtin holds the definitions of common SeaSonde products, keyed by name
+var Builtin = map[string]Product{
+	RangeSeriesName: RangeSeries,
+	"CSS": {
+		Name:             "CSS",
+		Dir:              "CSS",
+		FilePathPattern:  `CSS_[A-Za-z0-9]+_\d{2}_\d{2}_\d{2}_\d{4}\.cs[s4]?(\.gz|\.bz2)?$`,
+		FileNamePattern:  `^CSS_[A-Za-z0-9]+_\d{2}_\d{2}_\d{2}_\d{4}\.cs[s4]?(\.gz|\.bz2)?$`,
+		TimestampPattern: `\d{2}_\d{2}_\d{2}_\d{4}`,
+		TimestampLayout:  "06_01_02_1504",
+	},
+	"CSQ": {
+		Name:             "CSQ",
+		Dir:              "CSQ",
+		FilePathPattern:  `CSQ_[A-Za-z0-9]+_\d{2}_\d{2}_\d{2}_\d{6}\.csq(\.gz|\.bz2)?$`,
+		FileNamePattern:  `^CSQ_[A-Za-z0-9]+_\d{2}_\d{2}_\d{2}_\d{6}\.csq(\.gz|\.bz2)?$`,
+		TimestampPattern: `\d{2}_\d{2}_\d{2}_\d{6}`,
+		TimestampLayout:  "06_01_02_150405",
+	},
+	"RDLi": {
+		Name:             "RDLi",
+		Dir:              "Radials",
+		FilePathPattern:  `RDLi_[A-Za-z0-9]+_\d{4}_\d{2}_\d{2}_\d{4}\.ruv(\.gz|\.bz2)?$`,
+		FileNamePattern:  `^RDLi_[A-Za-z0-9]+_\d{4}_\d{2}_\d{2}_\d{4}\.ruv(\.gz|\.bz2)?$`,
+		TimestampPattern: `\d{4}_\d{2}_\d{2}_\d{4}`,
+		TimestampLayout:  "2006_01_02_1504",
+	},
+	"RDLm": {
+		Name:             "RDLm",
+		Dir:              "Radials",
+		FilePathPattern:  `RDLm_[A-Za-z0-9]+_\d{4}_\d{2}_\d{2}_\d{4}\.ruv(\.gz|\.bz2)?$`,
+		FileNamePattern:  `^RDLm_[A-Za-z0-9]+_\d{4}_\d{2}_\d{2}_\d{4}\.ruv(\.gz|\.bz2)?$`,
+		TimestampPattern: `\d{4}_\d{2}_\d{2}_\d{4}`,
+		TimestampLayout:  "2006_01_02_1504",
+	},
+	// Wave files hold a month of data and are named by month, e.g. `WVLM_MGS1_2023_05_00.wls`
+	"WVLM": {
+		Name:             "WVLM",
+		Dir:              "Waves",
+		FilePathPattern:  `WVLM_[A-Za-z0-9]+_\d{4}_\d{2}_\d{2}\.wls(\.gz|\.bz2)?$`,
+		FileNamePattern:  `^WVLM_[A-Za-z0-9]+_\d{4}_\d{2}_\d{2}\.wls(\.gz|\.bz2)?$`,
+		TimestampPattern: `\d{4}_\d{2}`,
+		TimestampLayout:  "2006_01",
+	},
+}
+
+// Validate checks that all fields are set and that the patterns compile
+func (p Product) Validate() error {
+	if p.Name == "" || p.Dir == "" || p.TimestampLayout == "" {
+		return fmt.Errorf("product definitions require a name, dir and timestamp_layout")
+	}
+
+	for _, pattern := range []string{p.FilePathPattern, p.FileNamePattern, p.TimestampPattern} {
+		if pattern == "" {
+			return fmt.Errorf("product %s is missing a pattern", p.Name)
+		}
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("product %s has an invalid pattern: %v", p.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// Select looks up the named products among the built-in and custom definitions. Custom definitions take precedence.
+func Select(names []string, custom []Product) ([]Product, error) {
+	var res []Product
+
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+
+		i := slices.IndexFunc(custom, func(p Product) bool { return p.Name == name })
+		if i >= 0 {
+			res = append(res, custom[i])
+		} else if p, ok := Builtin[name]; ok {
+			res = append(res, p)
+		} else {
+			return nil, fmt.Errorf("unknown product '%s'", name)
+		}
+	}
+
+	return res, nil
+}
+
+// regexes caches the compiled patterns, since products are matched against and parse the timestamp of every file of a
+// site
+var regexes sync.Map
+
+func compile(pattern string) (*regexp.Regexp, error) {
+	if re, ok := regexes.Load(pattern); ok {
+		return re.(*regexp.Regexp), nil
+	}
+
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	cached, _ := regexes.LoadOrStore(pattern, re)
+	return cached.(*regexp.Regexp), nil
+}
+
+// fileNameRegex returns the compiled file name pattern, which must be valid
+func (p Product) fileNameRegex() *regexp.Regexp {
+	re, err := compile(p.FileNamePattern)
+	if err != nil {
+		panic(err)
+	}
+	return re
+}
+
+// TimestampRegex returns the compiled timestamp pattern
+func (p Product) TimestampRegex() (*regexp.Regexp, error) {
+	return compile(p.TimestampPattern)
+}
+
+// ForFile returns the first of the products whose file name pattern matches the file
+func ForFile(products []Product, path string) (Product, bool) {
+	for _, p := range products {
+		if p.fileNameRegex().MatchString(filepath.Base(path)) {
+			return p, true
+		}
+	}
+
+	return Product{}, false
+}
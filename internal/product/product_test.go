@@ -0,0 +1,110 @@
+package product
+
+import (
+	"path/filepath"
+	"regexp"
+	"testing"
+	"time"
+)
+
+func TestBuiltinProducts(t *testing.T) {
+	// Define test cases
+	tests := []struct {
+		product string
+		path    string
+		want    time.Time
+	}{
+		{"RangeSeries", "/MGS1/RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610.rs", time.Date(2023, 5, 17, 7, 6, 10, 0, time.UTC)},
+		{"CSS", "/MGS1/CSS/CSS_MGS1_23_05_17_0700.cs4", time.Date(2023, 5, 17, 7, 0, 0, 0, time.UTC)},
+		{"CSQ", "/MGS1/CSQ/CSQ_MGS1_23_05_17_070610.csq", time.Date(2023, 5, 17, 7, 6, 10, 0, time.UTC)},
+		{"RDLi", "/MGS1/Radials/RDLi_MGS1_2023_05_17_0700.ruv", time.Date(2023, 5, 17, 7, 0, 0, 0, time.UTC)},
+		{"RDLm", "/MGS1/Radials/RDLm_MGS1_2023_05_17_0700.ruv", time.Date(2023, 5, 17, 7, 0, 0, 0, time.UTC)},
+		{"WVLM", "/MGS1/Waves/WVLM_MGS1_2023_05_00.wls", time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.product, func(t *testing.T) {
+			prod := Builtin[tt.product]
+			if err := prod.Validate(); err != nil {
+				t.Fatalf("Validate() error = %v", err)
+			}
+
+			if !regexp.MustCompile(prod.FilePathPattern).MatchString(tt.path) {
+				t.Errorf("FilePathPattern does not match %v", tt.path)
+			}
+
+			got, ok := ForFile([]Product{Builtin["RangeSeries"], prod}, tt.path)
+			if !ok || got.Name != tt.product {
+				t.Errorf("ForFile() = %v, want %v", got.Name, tt.product)
+			}
+
+			timestampStr := regexp.MustCompile(prod.TimestampPattern).FindString(filepath.Base(tt.path))
+			timestamp, err := time.Parse(prod.TimestampLayout, timestampStr)
+			if err != nil {
+				t.Fatalf("Failed to parse timestamp: %v", err)
+			}
+			if !timestamp.Equal(tt.want) {
+				t.Errorf("timestamp = %v, want %v", timestamp, tt.want)
+			}
+		})
+	}
+}
+
+func TestForFileNoMatch(t *testing.T) {
+	// Define test cases
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"Extension without dot", "/MGS1/RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610_rs"},
+		{"Other extension", "/MGS1/RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610.rs.txt"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute test
+			got, ok := ForFile([]Product{RangeSeries}, tt.path)
+
+			// Assert results
+			if ok {
+				t.Errorf("ForFile() = %v, want no product", got.Name)
+			}
+			if regexp.MustCompile(RangeSeries.FilePathPattern).MatchString(tt.path) {
+				t.Errorf("FilePathPattern matches %v", tt.path)
+			}
+		})
+	}
+}
+
+func TestTimestampRegex(t *testing.T) {
+	// Execute test
+	first, err := RangeSeries.TimestampRegex()
+	if err != nil {
+		t.Fatalf("TimestampRegex() error = %v", err)
+	}
+	second, _ := RangeSeries.TimestampRegex()
+
+	// Assert results
+	if first != second {
+		t.Errorf("TimestampRegex() compiled the pattern again")
+	}
+	if _, err := (Product{Name: "Invalid", TimestampPattern: `(`}).TimestampRegex(); err == nil {
+		t.Errorf("TimestampRegex() error = nil, want error for invalid pattern")
+	}
+}
+
+func TestSelect(t *testing.T) {
+	custom := []Product{{Name: "CSS", Dir: "Spectra/CSS"}}
+
+	got, err := Select([]string{"RangeSeries", "CSS"}, custom)
+	if err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+	if len(got) != 2 || got[0].Name != "RangeSeries" || got[1].Dir != "Spectra/CSS" {
+		t.Errorf("Select() = %v, want built-in RangeSeries and custom CSS", got)
+	}
+
+	if _, err := Select([]string{"Unknown"}, nil); err == nil {
+		t.Errorf("Select() error = nil, want error for unknown product")
+	}
+}
@@ -0,0 +1,167 @@
+package read
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/bzip2"
+	"compress/gzip"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Separates the path of an archive from the path of a member inside it, e.g. `RangeSeries/2023/05/17.zip!/Rng_mgs1_2023_05_17_070610.rs`
+const ArchiveMemberSeparator = "!/"
+
+const (
+	zipSuffix    = ".zip"
+	tarSuffix    = ".tar"
+	tarGzSuffix  = ".tar.gz"
+	tgzSuffix    = ".tgz"
+	tarBz2Suffix = ".tar.bz2"
+	tbz2Suffix   = ".tbz2"
+)
+
+var archiveSuffixes = []string{zipSuffix, tarSuffix, tarGzSuffix, tgzSuffix, tarBz2Suffix, tbz2Suffix}
+
+// IsArchive reports whether the path names a zip or (optionally compressed) tar archive
+func IsArchive(archivePath string) bool {
+	for _, suffix := range archiveSuffixes {
+		if strings.HasSuffix(archivePath, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ArchiveMemberPath builds the identifier of a member inside an archive
+func ArchiveMemberPath(archivePath string, memberPath string) string {
+	return archivePath + ArchiveMemberSeparator + memberPath
+}
+
+// cleanMemberPath normalizes archive member paths such as `./2023/05/17/x.rs` to valid fs.FS paths
+func cleanMemberPath(memberPath string) string {
+	return strings.TrimPrefix(path.Clean("/"+memberPath), "/")
+}
+
+// ListArchiveMembers returns the paths of the regular files in the archive whose name matches the pattern, so that file
+// name patterns anchored at the start also match members in subdirectories
+func ListArchiveMembers(fsys fs.FS, archivePath string, pattern string) ([]string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var memberPaths []string
+	if strings.HasSuffix(archivePath, zipSuffix) {
+		memberPaths, err = listZipMembers(fsys, archivePath)
+	} else {
+		err = walkTar(fsys, archivePath, func(header *tar.Header, _ io.Reader) error {
+			if header.Typeflag == tar.TypeReg {
+				memberPaths = append(memberPaths, header.Name)
+			}
+			return nil
+		})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	for _, memberPath := range memberPaths {
+		memberPath = cleanMemberPath(memberPath)
+		if re.MatchString(path.Base(memberPath)) {
+			res = append(res, memberPath)
+		}
+	}
+
+	return res, nil
+}
+
+// openZip opens a zip archive from the filesystem, reading it into memory if the file doesn't support random access.
+// The returned closer must be closed once the reader is no longer used.
+func openZip(fsys fs.FS, archivePath string) (*zip.Reader, io.Closer, error) {
+	file, err := fsys.Open(archivePath)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	var reader *zip.Reader
+	if readerAt, ok := file.(io.ReaderAt); ok {
+		reader, err = zip.NewReader(readerAt, info.Size())
+	} else {
+		var data []byte
+		data, err = io.ReadAll(file)
+		if err == nil {
+			reader, err = zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		}
+	}
+	if err != nil {
+		file.Close()
+		return nil, nil, err
+	}
+
+	return reader, file, nil
+}
+
+func listZipMembers(fsys fs.FS, archivePath string) ([]string, error) {
+	reader, closer, err := openZip(fsys, archivePath)
+	if err != nil {
+		return nil, err
+	}
+	defer closer.Close()
+
+	var res []string
+	for _, file := range reader.File {
+		if file.FileInfo().Mode().IsRegular() {
+			res = append(res, file.Name)
+		}
+	}
+
+	return res, nil
+}
+
+// walkTar calls fn for each entry of a tar archive, decompressing it according to its file ending
+func walkTar(fsys fs.FS, archivePath string, fn func(header *tar.Header, contents io.Reader) error) error {
+	file, err := fsys.Open(archivePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var stream io.Reader = file
+	switch {
+	case strings.HasSuffix(archivePath, tarGzSuffix), strings.HasSuffix(archivePath, tgzSuffix):
+		gzipReader, err := gzip.NewReader(file)
+		if err != nil {
+			return err
+		}
+		defer gzipReader.Close()
+		stream = gzipReader
+	case strings.HasSuffix(archivePath, tarBz2Suffix), strings.HasSuffix(archivePath, tbz2Suffix):
+		stream = bzip2.NewReader(file)
+	}
+
+	reader := tar.NewReader(stream)
+	for {
+		header, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		if err := fn(header, reader); err != nil {
+			return err
+		}
+	}
+}
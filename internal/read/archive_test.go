@@ -0,0 +1,156 @@
+package read
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/product"
+)
+
+const testMemberPattern = `.rs(\.gz|\.bz2)?$`
+
+var testMembers = map[string]string{
+	"Rng_mgs1_2023_05_17_000000.rs":               "first",
+	"2023/05/17/Rng_mgs1_2023_05_17_003000.rs.gz": "second",
+	"notes.txt": "notes",
+}
+
+func zipArchive(t *testing.T) []byte {
+	var buf bytes.Buffer
+
+	writer := zip.NewWriter(&buf)
+	for name, contents := range testMembers {
+		w, err := writer.Create(name)
+		if err != nil {
+			t.Fatalf("Failed to add archive member: %v", err)
+		}
+		w.Write([]byte(contents))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func tarArchive(t *testing.T) []byte {
+	var buf bytes.Buffer
+
+	writer := tar.NewWriter(&buf)
+	if err := writer.WriteHeader(&tar.Header{Name: "./2023/", Typeflag: tar.TypeDir, Mode: 0755}); err != nil {
+		t.Fatalf("Failed to add archive member: %v", err)
+	}
+	for name, contents := range testMembers {
+		header := &tar.Header{Name: "./" + name, Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(contents))}
+		if err := writer.WriteHeader(header); err != nil {
+			t.Fatalf("Failed to add archive member: %v", err)
+		}
+		writer.Write([]byte(contents))
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func tarGzArchive(t *testing.T) []byte {
+	var buf bytes.Buffer
+
+	gzipWriter := gzip.NewWriter(&buf)
+	gzipWriter.Write(tarArchive(t))
+	if err := gzipWriter.Close(); err != nil {
+		t.Fatalf("Failed to compress archive: %v", err)
+	}
+
+	return buf.Bytes()
+}
+
+func TestArchives(t *testing.T) {
+	// Define test cases
+	tests := []struct {
+		name        string
+		archivePath string
+		archive     func(t *testing.T) []byte
+	}{
+		{"Zip archive", "RangeSeries/2023/05/2023_05_17.zip", zipArchive},
+		{"Tar archive", "RangeSeries/2023/05/2023_05_17.tar", tarArchive},
+		{"Gzipped tar archive", "RangeSeries/2023/05/2023_05_17.tar.gz", tarGzArchive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fsys := fstest.MapFS{tt.archivePath: &fstest.MapFile{Data: tt.archive(t)}}
+
+			if !IsArchive(tt.archivePath) {
+				t.Errorf("IsArchive(%v) = false, want true", tt.archivePath)
+			}
+
+			// Members are listed by their paths within the archive
+			got, err := ListArchiveMembers(fsys, tt.archivePath, testMemberPattern)
+			if err != nil {
+				t.Fatalf("ListArchiveMembers() error = %v", err)
+			}
+			want := []string{"2023/05/17/Rng_mgs1_2023_05_17_003000.rs.gz", "Rng_mgs1_2023_05_17_000000.rs"}
+			if !reflect.DeepEqual(sorted(got), want) {
+				t.Errorf("ListArchiveMembers() = %v, want %v", got, want)
+			}
+
+			// The archive can be browsed as a filesystem
+			archiveFS, err := NewArchiveFS(fsys, tt.archivePath)
+			if err != nil {
+				t.Fatalf("NewArchiveFS() error = %v", err)
+			}
+
+			files, err := FindFilesMatchingPattern(archiveFS, ".", testMemberPattern, false)
+			if err != nil {
+				t.Fatalf("FindFilesMatchingPattern() error = %v", err)
+			}
+			if !reflect.DeepEqual(files, want) {
+				t.Errorf("FindFilesMatchingPattern() = %v, want %v", files, want)
+			}
+
+			for name, want := range testMembers {
+				contents, err := fs.ReadFile(archiveFS, name)
+				if err != nil || string(contents) != want {
+					t.Errorf("ReadFile(%v) = %q, %v, want %q", name, contents, err, want)
+				}
+			}
+			if err := fstest.TestFS(archiveFS, "notes.txt", "2023/05/17/Rng_mgs1_2023_05_17_003000.rs.gz"); err != nil {
+				t.Errorf("TestFS() error = %v", err)
+			}
+		})
+	}
+}
+
+func TestListArchiveMembersMatchesName(t *testing.T) {
+	// Arrange
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for _, name := range []string{"17/CSS_MGS1_23_05_17_0700.cs4", "17/notes/CSS_readme.txt"} {
+		if _, err := writer.Create(name); err != nil {
+			t.Fatalf("Failed to add archive member: %v", err)
+		}
+	}
+	if err := writer.Close(); err != nil {
+		t.Fatalf("Failed to write archive: %v", err)
+	}
+	fsys := fstest.MapFS{"CSS/2023/05/17.zip": &fstest.MapFile{Data: buf.Bytes()}}
+
+	// Execute test
+	got, err := ListArchiveMembers(fsys, "CSS/2023/05/17.zip", product.Builtin["CSS"].FileNamePattern)
+
+	// Assert results
+	if err != nil {
+		t.Fatalf("ListArchiveMembers() error = %v", err)
+	}
+	if want := []string{"17/CSS_MGS1_23_05_17_0700.cs4"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ListArchiveMembers() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,100 @@
+package read
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Starts a comment line in newline-delimited file lists
+const fileListComment = "#"
+
+// ReadFileList reads a list of paths, one per line, skipping blank lines and lines starting with `#`. With
+// nullDelimited, paths are separated by NUL bytes instead, as written by `find -print0`, and taken verbatim.
+func ReadFileList(r io.Reader, nullDelimited bool) ([]string, error) {
+	var res []string
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	if nullDelimited {
+		scanner.Split(ScanNull)
+	}
+
+	for scanner.Scan() {
+		entry := scanner.Text()
+		if !nullDelimited {
+			entry = strings.TrimSpace(entry)
+			if strings.HasPrefix(entry, fileListComment) {
+				continue
+			}
+		}
+
+		if entry != "" {
+			res = append(res, entry)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ScanNull is a bufio.SplitFunc splitting on NUL bytes
+func ScanNull(data []byte, atEOF bool) (int, []byte, error) {
+	if i := bytes.IndexByte(data, 0); i >= 0 {
+		return i + 1, data[:i], nil
+	}
+	if atEOF && len(data) > 0 {
+		return len(data), data, nil
+	}
+	return 0, nil, nil
+}
+
+// ExpandFileList resolves the entries of a file list. Absolute paths are kept, or expanded if they are glob patterns.
+// Relative paths and patterns, e.g. `2023/05/*/*.rs`, are resolved against each of dirs within the site.
+func ExpandFileList(site Site, dirs []string, entries []string) ([]string, error) {
+	var res []string
+
+	for _, entry := range entries {
+		if filepath.IsAbs(entry) || strings.Contains(entry, ArchiveMemberSeparator) {
+			if !strings.ContainsAny(entry, "*?[") {
+				res = append(res, entry)
+				continue
+			}
+
+			matches, err := filepath.Glob(entry)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern '%s': %v", entry, err)
+			}
+			res = append(res, matches...)
+			continue
+		}
+
+		var matches []string
+		for _, dir := range dirs {
+			dirMatches, err := fs.Glob(site.FS, path.Join(dir, filepath.ToSlash(entry)))
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern '%s': %v", entry, err)
+			}
+
+			for _, match := range dirMatches {
+				matches = append(matches, site.Path(match))
+			}
+		}
+
+		if len(matches) == 0 {
+			log.Printf("Warning: '%s' does not match any files\n", entry)
+		}
+		res = append(res, matches...)
+	}
+
+	return res, nil
+}
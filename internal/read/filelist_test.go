@@ -0,0 +1,75 @@
+package read
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestReadFileList(t *testing.T) {
+	// Define test cases
+	tests := []struct {
+		name          string
+		input         string
+		nullDelimited bool
+		want          []string
+	}{
+		{
+			name:  "Lines with comments",
+			input: "# May 2023\n/data/Rng_mgs1_2023_05_17_070610.rs\n\n  2023/05/*/*.rs  \r\n",
+			want:  []string{"/data/Rng_mgs1_2023_05_17_070610.rs", "2023/05/*/*.rs"},
+		},
+		{
+			name:          "NUL-delimited",
+			input:         "./2023/05/17/a b.rs\x00#odd name.rs\x00",
+			nullDelimited: true,
+			want:          []string{"./2023/05/17/a b.rs", "#odd name.rs"},
+		},
+		{
+			name:  "Empty",
+			input: "",
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ReadFileList(strings.NewReader(tt.input), tt.nullDelimited)
+			if err != nil {
+				t.Fatalf("ReadFileList() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ReadFileList() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExpandFileList(t *testing.T) {
+	// Arrange
+	site := NewSite(testSite, "/archive/UCSB/MGS1")
+	entries := []string{
+		"/elsewhere/Rng_mgs1_2023_05_17_000000.rs",
+		"2023/05/*/*.rs",
+		"./2023/05/17/Rng_mgs1_2023_05_17_070610.rs",
+		"2023/06/*/*.rs",
+	}
+
+	expected := []string{
+		"/elsewhere/Rng_mgs1_2023_05_17_000000.rs",
+		"/archive/UCSB/MGS1/RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610.rs",
+		"/archive/UCSB/MGS1/RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_073610.rs",
+		"/archive/UCSB/MGS1/RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610.rs",
+	}
+
+	// Execute test
+	got, err := ExpandFileList(site, []string{"RangeSeries"}, entries)
+	if err != nil {
+		t.Fatalf("ExpandFileList() error = %v", err)
+	}
+
+	// Assert results
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("ExpandFileList() = %v, want %v", got, expected)
+	}
+}
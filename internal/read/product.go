@@ -0,0 +1,39 @@
+package read
+
+import (
+	"git.axiom/axiom/range-series-config-mapper/internal/product"
+)
+
+// Matches the daily (or monthly) archives of product files, e.g. `RangeSeries/2023/05/17.zip`
+const ProductArchivePattern = `\d{4}\/\d{2}\/.*\.(zip|tar|tar\.gz|tgz|tar\.bz2|tbz2)$`
+
+// FindProductFiles returns the display paths of the product's files in the site, including the members of archives
+// in the product's directory. Date directories outside the time range are not scanned.
+func FindProductFiles(site Site, prod product.Product, timeRange TimeRange) ([]string, error) {
+	paths, err := FindFilesMatchingPatternInRange(site.FS, prod.Dir, prod.FilePathPattern, false, timeRange)
+	if err != nil {
+		return nil, err
+	}
+
+	archivePaths, err := FindFilesMatchingPatternInRange(site.FS, prod.Dir, ProductArchivePattern, false, timeRange)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []string
+	for _, path := range paths {
+		res = append(res, site.Path(path))
+	}
+	for _, archivePath := range archivePaths {
+		members, err := ListArchiveMembers(site.FS, archivePath, prod.FileNamePattern)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, member := range members {
+			res = append(res, ArchiveMemberPath(site.Path(archivePath), member))
+		}
+	}
+
+	return res, nil
+}
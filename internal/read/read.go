@@ -1,22 +1,31 @@
 package read
 
 import (
+	"errors"
+	"io/fs"
 	"log"
-	"os"
-	"path/filepath"
+	"path"
 	"regexp"
+	"slices"
+	"strings"
 )
 
-func FindFilesMatchingPattern(baseDir string, pattern string, wantDirectories bool) ([]string, error) {
+func FindFilesMatchingPattern(fsys fs.FS, baseDir string, pattern string, wantDirectories bool) ([]string, error) {
+	return FindFilesMatchingPatternInRange(fsys, baseDir, pattern, wantDirectories, TimeRange{})
+}
+
+// FindFilesMatchingPatternInRange is FindFilesMatchingPattern, skipping the date directories below baseDir (e.g.
+// `2023/05/17`) that lie outside the time range
+func FindFilesMatchingPatternInRange(fsys fs.FS, baseDir string, pattern string, wantDirectories bool, timeRange TimeRange) ([]string, error) {
 	var matchingFiles []string
 	re, err := regexp.Compile(pattern)
 	if err != nil {
 		return nil, err
 	}
 
-	err = filepath.WalkDir(baseDir, func(path string, d os.DirEntry, err error) error {
+	err = fs.WalkDir(fsys, baseDir, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
-			if os.IsPermission(err) {
+			if errors.Is(err, fs.ErrPermission) {
 				log.Printf("Warning: Permission denied accessing %s, skipping.\n", path)
 				return nil
 			}
@@ -25,6 +34,10 @@ func FindFilesMatchingPattern(baseDir string, pattern string, wantDirectories bo
 			return err
 		}
 
+		if d.IsDir() && timeRange.excludesDateDir(relDir(baseDir, path)) {
+			return fs.SkipDir
+		}
+
 		// Check if the file is the correct type (file/directory) and if it matches the pattern
 		if d.IsDir() == wantDirectories && re.MatchString(path) {
 			matchingFiles = append(matchingFiles, path)
@@ -38,3 +51,70 @@ func FindFilesMatchingPattern(baseDir string, pattern string, wantDirectories bo
 
 	return matchingFiles, nil
 }
+
+// DirMatches are the directories found by FindMatchingDirs
+type DirMatches struct {
+	// The matching directories within the depth limit that are not inside another match
+	Dirs []string
+	// Matching directories inside another match, e.g. a copy of an older config within a config
+	Nested []string
+	// Matching directories below the depth limit that are not inside another match
+	TooDeep []string
+}
+
+// FindMatchingDirs finds the directories below baseDir matching the pattern, up to maxDepth levels deep. Matches that
+// are nested inside another match or lie deeper are reported separately.
+func FindMatchingDirs(fsys fs.FS, baseDir string, pattern string, maxDepth int) (DirMatches, error) {
+	var res DirMatches
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return res, err
+	}
+
+	err = fs.WalkDir(fsys, baseDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrPermission) {
+				log.Printf("Warning: Permission denied accessing %s, skipping.\n", path)
+				return nil
+			}
+			return err
+		}
+
+		if !d.IsDir() || path == baseDir || !re.MatchString(path) {
+			return nil
+		}
+
+		rel := relDir(baseDir, path)
+		switch {
+		case slices.ContainsFunc(res.Dirs, func(dir string) bool { return strings.HasPrefix(path, dir+"/") }):
+			res.Nested = append(res.Nested, path)
+		case strings.Count(rel, "/")+1 > maxDepth:
+			res.TooDeep = append(res.TooDeep, path)
+		default:
+			res.Dirs = append(res.Dirs, path)
+		}
+		return nil
+	})
+
+	if err != nil {
+		return DirMatches{}, err
+	}
+
+	return res, nil
+}
+
+// FindMissingFiles returns the entries of requiredFiles (relative paths) that do not exist in dir
+func FindMissingFiles(fsys fs.FS, dir string, requiredFiles []string) ([]string, error) {
+	var missingFiles []string
+
+	for _, requiredFile := range requiredFiles {
+		_, err := fs.Stat(fsys, path.Join(dir, requiredFile))
+		if errors.Is(err, fs.ErrNotExist) {
+			missingFiles = append(missingFiles, requiredFile)
+		} else if err != nil {
+			return nil, err
+		}
+	}
+
+	return missingFiles, nil
+}
@@ -0,0 +1,160 @@
+package read
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+// A site tree with two auto configs, one operator config and a day of RangeSeries files
+var testSite = fstest.MapFS{
+	"Config_Auto/20230501T000000Z/Header.txt":                           {Data: []byte("MGS1 ! Site Code\n")},
+	"Config_Auto/20230520T120000Z/Header.txt":                           {Data: []byte("MGS1 ! Site Code\n")},
+	"Config_Auto/20230520T120000Z/20230101T000000Z/Header.txt":          {Data: []byte("nested\n")},
+	"Config_Operator/20230510T000000Z-20230515T000000Z/MeasPattern.txt": {Data: []byte("pattern\n")},
+	"RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610.rs":              {},
+	"RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_073610.rs":              {},
+	"RangeSeries/2023/05/17/notes.txt":                                  {},
+}
+
+func sorted(paths []string) []string {
+	paths = slices.Clone(paths)
+	slices.Sort(paths)
+	return paths
+}
+
+func TestFindFilesMatchingPattern(t *testing.T) {
+	// Define test cases
+	tests := []struct {
+		name            string
+		baseDir         string
+		pattern         string
+		wantDirectories bool
+		want            []string
+	}{
+		{
+			name:            "Config directories",
+			baseDir:         "Config_Auto",
+			pattern:         `\d{8}T\d{6}Z$`,
+			wantDirectories: true,
+			want: []string{
+				"Config_Auto/20230501T000000Z",
+				"Config_Auto/20230520T120000Z",
+				"Config_Auto/20230520T120000Z/20230101T000000Z",
+			},
+		},
+		{
+			name:            "RangeSeries files",
+			baseDir:         "RangeSeries",
+			pattern:         `\d{4}\/\d{2}\/\d{2}/.*.rs$`,
+			wantDirectories: false,
+			want: []string{
+				"RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610.rs",
+				"RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_073610.rs",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FindFilesMatchingPattern(testSite, tt.baseDir, tt.pattern, tt.wantDirectories)
+			if err != nil {
+				t.Fatalf("FindFilesMatchingPattern() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindFilesMatchingPattern() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindMatchingDirs(t *testing.T) {
+	// Arrange
+	fsys := fstest.MapFS{
+		"Config_Auto/20230501T000000Z/Header.txt":                  {},
+		"Config_Auto/20230520T120000Z/20230101T000000Z/Header.txt": {},
+		"Config_Auto/2022/20221201T000000Z/Header.txt":             {},
+	}
+
+	// Define test cases
+	tests := []struct {
+		name     string
+		maxDepth int
+		want     DirMatches
+	}{
+		{
+			name:     "Immediate children",
+			maxDepth: 1,
+			want: DirMatches{
+				Dirs:    []string{"Config_Auto/20230501T000000Z", "Config_Auto/20230520T120000Z"},
+				Nested:  []string{"Config_Auto/20230520T120000Z/20230101T000000Z"},
+				TooDeep: []string{"Config_Auto/2022/20221201T000000Z"},
+			},
+		},
+		{
+			name:     "Grouped by year",
+			maxDepth: 2,
+			want: DirMatches{
+				Dirs:   []string{"Config_Auto/2022/20221201T000000Z", "Config_Auto/20230501T000000Z", "Config_Auto/20230520T120000Z"},
+				Nested: []string{"Config_Auto/20230520T120000Z/20230101T000000Z"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute test
+			got, err := FindMatchingDirs(fsys, "Config_Auto", `\d{8}T\d{6}Z$`, tt.maxDepth)
+
+			// Assert results
+			if err != nil {
+				t.Fatalf("FindMatchingDirs() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FindMatchingDirs() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFindMissingFiles(t *testing.T) {
+	got, err := FindMissingFiles(testSite, "Config_Operator/20230510T000000Z-20230515T000000Z", []string{"Header.txt", "MeasPattern.txt"})
+	if err != nil {
+		t.Fatalf("FindMissingFiles() error = %v", err)
+	}
+
+	want := []string{"Header.txt"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("FindMissingFiles() = %v, want %v", got, want)
+	}
+}
+
+func TestSitePaths(t *testing.T) {
+	// Define test cases
+	tests := []struct {
+		name        string
+		site        Site
+		displayPath string
+	}{
+		{"Directory", NewSite(testSite, "/archive/UCSB/MGS1"), "/archive/UCSB/MGS1/Config_Auto/20230501T000000Z"},
+		{"Archive", Site{FS: testSite, Root: "snapshot.zip!/UCSB/MGS1", archive: true}, "snapshot.zip!/UCSB/MGS1/Config_Auto/20230501T000000Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.site.Path("Config_Auto/20230501T000000Z"); got != tt.displayPath {
+				t.Errorf("Path() = %v, want %v", got, tt.displayPath)
+			}
+
+			got, err := tt.site.Rel(tt.displayPath)
+			if err != nil || got != "Config_Auto/20230501T000000Z" {
+				t.Errorf("Rel() = %v, %v, want %v", got, err, "Config_Auto/20230501T000000Z")
+			}
+
+			if _, err := tt.site.Rel("/elsewhere/Config_Auto/20230501T000000Z"); err == nil {
+				t.Errorf("Rel() error = nil, want error for path outside the site")
+			}
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package read
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Site is a HF Radar site directory on a filesystem. Files are found using paths relative to the site on FS, but
+// reported using the site's display paths: OS paths for directories, or archive member identifiers for snapshots
+// such as `snapshot.tar.gz!/UCSB/MGS1/Config_Auto/20230517T000000Z`.
+type Site struct {
+	FS fs.FS
+	// Display path of the site directory
+	Root string
+	// Whether Root refers to a directory inside an archive
+	archive bool
+}
+
+// NewSite returns a site backed by fsys, with display paths relative to the OS path root
+func NewSite(fsys fs.FS, root string) Site {
+	return Site{FS: fsys, Root: root}
+}
+
+// OpenSite opens the site directory at siteDir. Zip and (optionally compressed) tar archives are opened as snapshots,
+// with an optional directory inside the archive given after `!/`, e.g. `snapshot.zip!/UCSB/MGS1`.
+func OpenSite(siteDir string) (Site, error) {
+	archivePath, subDir, isMember := strings.Cut(siteDir, ArchiveMemberSeparator)
+	if !isMember && !IsArchive(siteDir) {
+		return NewSite(os.DirFS(siteDir), siteDir), nil
+	}
+
+	fsys, err := NewArchiveFS(os.DirFS(filepath.Dir(archivePath)), filepath.Base(archivePath))
+	if err != nil {
+		return Site{}, err
+	}
+
+	root := archivePath + "!"
+	subDir = strings.Trim(subDir, "/")
+	if subDir != "" {
+		fsys, err = fs.Sub(fsys, subDir)
+		if err != nil {
+			return Site{}, err
+		}
+		root += "/" + subDir
+	}
+
+	return Site{FS: fsys, Root: root, archive: true}, nil
+}
+
+// IsSnapshot reports whether the site is a directory inside an archive rather than on the OS filesystem
+func (s Site) IsSnapshot() bool {
+	return s.archive
+}
+
+// Path converts a path relative to the site on FS into a display path
+func (s Site) Path(name string) string {
+	if s.archive {
+		return s.Root + "/" + name
+	}
+	return filepath.Join(s.Root, filepath.FromSlash(name))
+}
+
+// Rel converts a display path of a file within the site into a path relative to the site on FS
+func (s Site) Rel(displayPath string) (string, error) {
+	var name string
+	if s.archive {
+		name = strings.TrimPrefix(displayPath, s.Root+"/")
+	} else if rel, err := filepath.Rel(s.Root, displayPath); err == nil {
+		name = filepath.ToSlash(rel)
+	}
+
+	if name == "" || !fs.ValidPath(name) || (s.archive && name == displayPath) {
+		return "", fmt.Errorf("%s is not within site %s", displayPath, s.Root)
+	}
+	return name, nil
+}
+
+// NewArchiveFS returns a read-only filesystem for the contents of a zip or (optionally compressed) tar archive
+func NewArchiveFS(fsys fs.FS, archivePath string) (fs.FS, error) {
+	if !IsArchive(archivePath) {
+		return nil, fmt.Errorf("%s is not a supported archive", archivePath)
+	}
+
+	if strings.HasSuffix(archivePath, zipSuffix) {
+		// The archive stays open for as long as the filesystem is used
+		reader, _, err := openZip(fsys, archivePath)
+		return reader, err
+	}
+
+	return newTarFS(fsys, archivePath)
+}
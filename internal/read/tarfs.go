@@ -0,0 +1,234 @@
+package read
+
+import (
+	"archive/tar"
+	"bytes"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// tarFS serves the contents of a tar archive, which is scanned once when it is opened. Since compressed tar archives
+// can't be read at random, their members are decompressed into memory. Members of uncompressed archives are read on
+// demand from the archive, which stays open for as long as the filesystem is used.
+type tarFS struct {
+	entries map[string]*tarEntry
+	// The uncompressed archive that members without data are read from
+	archive io.ReaderAt
+}
+
+// tarEntry is a member of a tar archive, or a directory only implied by the paths of its members
+type tarEntry struct {
+	name     string
+	mode     fs.FileMode
+	modTime  time.Time
+	size     int64
+	data     []byte
+	offset   int64
+	children []string
+}
+
+// countingReader counts the bytes read, so that the offsets of the members of an uncompressed archive are known
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.reader.Read(p)
+	c.n += int64(n)
+	return n, err
+}
+
+func newTarFS(fsys fs.FS, archivePath string) (*tarFS, error) {
+	res := &tarFS{entries: map[string]*tarEntry{".": {name: ".", mode: fs.ModeDir | 0555}}}
+
+	if strings.HasSuffix(archivePath, tarSuffix) {
+		file, err := fsys.Open(archivePath)
+		if err != nil {
+			return nil, err
+		}
+
+		if readerAt, ok := file.(io.ReaderAt); ok {
+			counter := &countingReader{reader: file}
+			reader := tar.NewReader(counter)
+			for {
+				header, err := reader.Next()
+				if err == io.EOF {
+					break
+				} else if err != nil {
+					file.Close()
+					return nil, err
+				}
+
+				// The reader stops at the start of the member's contents
+				res.add(header, nil, counter.n)
+			}
+
+			res.archive = readerAt
+			return res, nil
+		}
+		file.Close()
+	}
+
+	err := walkTar(fsys, archivePath, func(header *tar.Header, contents io.Reader) error {
+		var data []byte
+		if header.Typeflag == tar.TypeReg {
+			var err error
+			data, err = io.ReadAll(contents)
+			if err != nil {
+				return err
+			}
+		}
+
+		res.add(header, data, 0)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// add records a directory or regular file of the archive, along with the directories implied by its path
+func (t *tarFS) add(header *tar.Header, data []byte, offset int64) {
+	name := cleanMemberPath(header.Name)
+	if name == "" || (header.Typeflag != tar.TypeDir && header.Typeflag != tar.TypeReg) {
+		return
+	}
+
+	entry := t.dir(name)
+	entry.mode, entry.modTime = header.FileInfo().Mode().Perm(), header.ModTime
+	if header.Typeflag == tar.TypeDir {
+		entry.mode |= fs.ModeDir
+	} else {
+		entry.size, entry.data, entry.offset = header.Size, data, offset
+	}
+}
+
+// dir returns the entry of the path, adding it and its parents as directories if they don't exist yet
+func (t *tarFS) dir(name string) *tarEntry {
+	if entry, ok := t.entries[name]; ok {
+		return entry
+	}
+
+	entry := &tarEntry{name: path.Base(name), mode: fs.ModeDir | 0555}
+	t.entries[name] = entry
+
+	parent := t.dir(path.Dir(name))
+	parent.children = append(parent.children, name)
+
+	return entry
+}
+
+func (t *tarFS) lookup(op string, name string) (*tarEntry, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrInvalid}
+	}
+
+	entry, ok := t.entries[name]
+	if !ok {
+		return nil, &fs.PathError{Op: op, Path: name, Err: fs.ErrNotExist}
+	}
+
+	return entry, nil
+}
+
+func (t *tarFS) Open(name string) (fs.File, error) {
+	entry, err := t.lookup("open", name)
+	if err != nil {
+		return nil, err
+	}
+
+	if entry.IsDir() {
+		entries, _ := t.ReadDir(name)
+		return &tarDir{entry: entry, path: name, entries: entries}, nil
+	}
+
+	var reader io.Reader = bytes.NewReader(entry.data)
+	if t.archive != nil {
+		reader = io.NewSectionReader(t.archive, entry.offset, entry.size)
+	}
+	return &tarFile{entry: entry, reader: reader}, nil
+}
+
+func (t *tarFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entry, err := t.lookup("readdir", name)
+	if err != nil {
+		return nil, err
+	}
+	if !entry.IsDir() {
+		return nil, &fs.PathError{Op: "readdir", Path: name, Err: errors.New("not a directory")}
+	}
+
+	children := slices.Clone(entry.children)
+	slices.Sort(children)
+
+	res := make([]fs.DirEntry, len(children))
+	for i, child := range children {
+		res[i] = t.entries[child]
+	}
+
+	return res, nil
+}
+
+func (t *tarFS) Stat(name string) (fs.FileInfo, error) {
+	return t.lookup("stat", name)
+}
+
+// tarEntry implements both fs.FileInfo and fs.DirEntry
+
+func (e *tarEntry) Name() string               { return e.name }
+func (e *tarEntry) Size() int64                { return e.size }
+func (e *tarEntry) Mode() fs.FileMode          { return e.mode }
+func (e *tarEntry) ModTime() time.Time         { return e.modTime }
+func (e *tarEntry) IsDir() bool                { return e.mode.IsDir() }
+func (e *tarEntry) Sys() any                   { return nil }
+func (e *tarEntry) Type() fs.FileMode          { return e.mode.Type() }
+func (e *tarEntry) Info() (fs.FileInfo, error) { return e, nil }
+
+// tarFile is an open regular file of a tar archive
+type tarFile struct {
+	entry  *tarEntry
+	reader io.Reader
+}
+
+func (f *tarFile) Stat() (fs.FileInfo, error) { return f.entry, nil }
+func (f *tarFile) Read(p []byte) (int, error) { return f.reader.Read(p) }
+func (f *tarFile) Close() error               { return nil }
+
+// tarDir is an open directory of a tar archive
+type tarDir struct {
+	entry   *tarEntry
+	path    string
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *tarDir) Stat() (fs.FileInfo, error) { return d.entry, nil }
+func (d *tarDir) Close() error               { return nil }
+
+func (d *tarDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.path, Err: errors.New("is a directory")}
+}
+
+// ReadDir returns the next n entries of the directory, following the semantics of fs.ReadDirFile
+func (d *tarDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+		return remaining, nil
+	}
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+
+	n = min(n, len(remaining))
+	d.offset += n
+	return remaining[:n], nil
+}
@@ -0,0 +1,81 @@
+package read
+
+import (
+	"path"
+	"time"
+)
+
+// Layouts of the date directories products are organized in, e.g. `RangeSeries/2023/05/17`, by nesting depth
+var dateDirLayouts = []string{"2006", "2006/01", "2006/01/02"}
+
+// TimeRange limits scans to [Start, End). A zero Start or End leaves that side of the range open.
+type TimeRange struct {
+	Start time.Time
+	End   time.Time
+}
+
+// IsZero reports whether the range is open on both sides
+func (r TimeRange) IsZero() bool {
+	return r.Start.IsZero() && r.End.IsZero()
+}
+
+// Widen extends each bounded side of the range by d
+func (r TimeRange) Widen(d time.Duration) TimeRange {
+	if !r.Start.IsZero() {
+		r.Start = r.Start.Add(-d)
+	}
+	if !r.End.IsZero() {
+		r.End = r.End.Add(d)
+	}
+	return r
+}
+
+// Contains reports whether the timestamp falls within the range
+func (r TimeRange) Contains(timestamp time.Time) bool {
+	return (r.Start.IsZero() || !timestamp.Before(r.Start)) && (r.End.IsZero() || timestamp.Before(r.End))
+}
+
+// overlaps reports whether [start, end) intersects the range
+func (r TimeRange) overlaps(start time.Time, end time.Time) bool {
+	return (r.Start.IsZero() || end.After(r.Start)) && (r.End.IsZero() || start.Before(r.End))
+}
+
+// excludesDateDir reports whether the directory, relative to a product's base directory, is a `YYYY`, `YYYY/MM` or
+// `YYYY/MM/DD` date directory lying entirely outside the range. Other directories are never excluded.
+func (r TimeRange) excludesDateDir(rel string) bool {
+	if r.IsZero() || rel == "." {
+		return false
+	}
+
+	for i, layout := range dateDirLayouts {
+		if len(rel) != len(layout) {
+			continue
+		}
+
+		start, err := time.Parse(layout, rel)
+		if err != nil {
+			return false
+		}
+
+		var end time.Time
+		switch i {
+		case 0:
+			end = start.AddDate(1, 0, 0)
+		case 1:
+			end = start.AddDate(0, 1, 0)
+		default:
+			end = start.AddDate(0, 0, 1)
+		}
+		return !r.overlaps(start, end)
+	}
+
+	return false
+}
+
+// relDir returns the path of dir relative to baseDir, which must contain it
+func relDir(baseDir string, dir string) string {
+	if dir == baseDir {
+		return "."
+	}
+	return path.Clean(dir[len(baseDir)+1:])
+}
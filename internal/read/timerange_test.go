@@ -0,0 +1,74 @@
+package read
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+)
+
+func TestExcludesDateDir(t *testing.T) {
+	timeRange := TimeRange{
+		Start: time.Date(2023, 5, 17, 12, 0, 0, 0, time.UTC),
+		End:   time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC),
+	}
+
+	// Define test cases
+	tests := []struct {
+		rel  string
+		want bool
+	}{
+		{".", false},
+		{"2022", true},
+		{"2023", false},
+		{"2023/04", true},
+		{"2023/05", false},
+		{"2023/06", true},
+		{"2023/05/16", true},
+		{"2023/05/17", false},
+		{"2023/05/31", false},
+		{"archive", false},
+		{"2023/05/xx", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.rel, func(t *testing.T) {
+			if got := timeRange.excludesDateDir(tt.rel); got != tt.want {
+				t.Errorf("excludesDateDir(%v) = %v, want %v", tt.rel, got, tt.want)
+			}
+		})
+	}
+
+	// An open range excludes nothing
+	if (TimeRange{}).excludesDateDir("1999") {
+		t.Errorf("excludesDateDir() of open range = true, want false")
+	}
+}
+
+func TestFindFilesMatchingPatternInRange(t *testing.T) {
+	// Arrange
+	fsys := fstest.MapFS{
+		"RangeSeries/2023/04/30/Rng_mgs1_2023_04_30_000000.rs": {},
+		"RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610.rs": {},
+		"RangeSeries/2023/05/18/Rng_mgs1_2023_05_18_070610.rs": {},
+		"RangeSeries/2024/01/01/Rng_mgs1_2024_01_01_000000.rs": {},
+	}
+	timeRange := TimeRange{Start: time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)}
+
+	expected := []string{
+		"RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610.rs",
+		"RangeSeries/2023/05/18/Rng_mgs1_2023_05_18_070610.rs",
+		"RangeSeries/2024/01/01/Rng_mgs1_2024_01_01_000000.rs",
+	}
+
+	// Execute test
+	got, err := FindFilesMatchingPatternInRange(fsys, "RangeSeries", `.rs$`, false, timeRange)
+	if err != nil {
+		t.Fatalf("FindFilesMatchingPatternInRange() error = %v", err)
+	}
+
+	// Assert results
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("FindFilesMatchingPatternInRange() = %v, want %v", got, expected)
+	}
+}
@@ -0,0 +1,323 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/config_interval"
+	"git.axiom/axiom/range-series-config-mapper/internal/mapping"
+	"git.axiom/axiom/range-series-config-mapper/internal/pathmap"
+	"git.axiom/axiom/range-series-config-mapper/internal/product"
+	"git.axiom/axiom/range-series-config-mapper/internal/siteindex"
+)
+
+const sitesPathPrefix = "/sites/"
+
+const (
+	lookupEndpoint    = "lookup"
+	configsEndpoint   = "configs"
+	intervalsEndpoint = "intervals"
+	findingsEndpoint  = "findings"
+)
+
+// Loader (re)builds the index of a site
+type Loader func() (*siteindex.Index, error)
+
+// Paths controls how paths are written to responses and read from requests
+type Paths struct {
+	Rules []pathmap.Rule
+	// Whether paths within a site directory are written relative to it
+	Relative bool
+}
+
+// Server answers config lookups for one or more sites from in-memory interval indexes
+type Server struct {
+	mu       sync.RWMutex
+	indexes  map[string]*siteindex.Index
+	loaders  map[string]Loader
+	products []product.Product
+	paths    Paths
+}
+
+type intervalResponse struct {
+	Kind   string    `json:"kind"`
+	Config string    `json:"config"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+}
+
+type lookupResponse struct {
+	Site    string     `json:"site"`
+	File    string     `json:"file,omitempty"`
+	Time    time.Time  `json:"time"`
+	RawTime *time.Time `json:"raw_time,omitempty"`
+	// Set for files stamped within the boundary grace before the start of their config's interval
+	SnappedTo  *time.Time        `json:"snapped_to,omitempty"`
+	OnBoundary bool              `json:"on_boundary,omitempty"`
+	Stale      bool              `json:"stale,omitempty"`
+	Unapproved string            `json:"unapproved,omitempty"`
+	Fallback   string            `json:"fallback,omitempty"`
+	Config     string            `json:"config"`
+	Kind       string            `json:"kind,omitempty"`
+	Interval   *intervalResponse `json:"interval,omitempty"`
+}
+
+type sourceResponse struct {
+	Name    string   `json:"name"`
+	Scheme  string   `json:"scheme"`
+	Configs []string `json:"configs"`
+}
+
+type configsResponse struct {
+	Site    string           `json:"site"`
+	Sources []sourceResponse `json:"sources"`
+}
+
+type intervalsResponse struct {
+	Site      string             `json:"site"`
+	LoadedAt  time.Time          `json:"loaded_at"`
+	Intervals []intervalResponse `json:"intervals"`
+}
+
+type findingsResponse struct {
+	Site     string              `json:"site"`
+	LoadedAt time.Time           `json:"loaded_at"`
+	Findings []siteindex.Finding `json:"findings"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+// New loads every site once. Files given by path are resolved using the first of the products they match, and paths
+// are rewritten according to paths.
+func New(loaders map[string]Loader, products []product.Product, paths Paths) (*Server, error) {
+	s := &Server{
+		indexes:  make(map[string]*siteindex.Index),
+		loaders:  loaders,
+		products: products,
+		paths:    paths,
+	}
+
+	for name, load := range loaders {
+		idx, err := load()
+		if err != nil {
+			return nil, fmt.Errorf("error loading site %s: %v", name, err)
+		}
+		s.indexes[name] = idx
+	}
+
+	return s, nil
+}
+
+// Rescan reloads every site. Sites that fail to load keep their previous index.
+func (s *Server) Rescan() {
+	for name, load := range s.loaders {
+		idx, err := load()
+		if err != nil {
+			log.Printf("Error rescanning site %s, keeping previous configs: %v\n", name, err)
+			continue
+		}
+
+		s.mu.Lock()
+		s.indexes[name] = idx
+		s.mu.Unlock()
+	}
+}
+
+// RescanEvery rescans the sites at the interval until stop is closed
+func (s *Server) RescanEvery(interval time.Duration, stop <-chan struct{}) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			log.Println("Rescanning sites...")
+			s.Rescan()
+		case <-stop:
+			return
+		}
+	}
+}
+
+func (s *Server) index(name string) (*siteindex.Index, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	idx, ok := s.indexes[name]
+	return idx, ok
+}
+
+// rewriter returns the rewriter for the paths of the site
+func (s *Server) rewriter(idx *siteindex.Index) pathmap.Rewriter {
+	var relativeTo string
+	if s.paths.Relative {
+		relativeTo = idx.Site.Root
+	}
+	return pathmap.New(s.paths.Rules, relativeTo)
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		log.Printf("Error writing response: %v\n", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, format string, args ...any) {
+	writeJSON(w, status, errorResponse{Error: fmt.Sprintf(format, args...)})
+}
+
+func newIntervalResponse(timeInterval config_interval.ConfigInterval, kind string, rewriter pathmap.Rewriter) intervalResponse {
+	return intervalResponse{Kind: kind, Config: rewriter.Output(timeInterval.Config), Start: timeInterval.Start, End: timeInterval.End}
+}
+
+// Handler routes `/sites` and `/sites/{site}/{lookup,configs,intervals,findings}`
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/sites", s.handleSites)
+	mux.HandleFunc(sitesPathPrefix, s.handleSite)
+	return mux
+}
+
+func (s *Server) handleSites(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+		return
+	}
+
+	s.mu.RLock()
+	var names []string
+	for name := range s.indexes {
+		names = append(names, name)
+	}
+	s.mu.RUnlock()
+	slices.Sort(names)
+
+	writeJSON(w, http.StatusOK, names)
+}
+
+func (s *Server) handleSite(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeError(w, http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+		return
+	}
+
+	name, endpoint, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, sitesPathPrefix), "/")
+	idx, ok := s.index(name)
+	if !ok {
+		writeError(w, http.StatusNotFound, "unknown site '%s'", name)
+		return
+	}
+
+	switch endpoint {
+	case lookupEndpoint:
+		s.handleLookup(w, r, idx)
+	case configsEndpoint:
+		s.handleConfigs(w, idx)
+	case intervalsEndpoint:
+		s.handleIntervals(w, idx)
+	case findingsEndpoint:
+		writeJSON(w, http.StatusOK, findingsResponse{Site: idx.Name, LoadedAt: idx.LoadedAt, Findings: idx.Findings})
+	default:
+		writeError(w, http.StatusNotFound, "unknown endpoint '%s'", endpoint)
+	}
+}
+
+// handleLookup resolves the config for a file (`path`) or a timestamp (`time`)
+func (s *Server) handleLookup(w http.ResponseWriter, r *http.Request, idx *siteindex.Index) {
+	query := r.URL.Query()
+	res := lookupResponse{Site: idx.Name}
+	rewriter := s.rewriter(idx)
+
+	var resolution mapping.Resolution
+	switch {
+	case query.Has("path") && query.Has("time"):
+		writeError(w, http.StatusBadRequest, "specify either 'path' or 'time', not both")
+		return
+	case query.Has("path"):
+		res.File = query.Get("path")
+		file := rewriter.Input(res.File)
+		prod, isProduct := product.ForFile(s.products, file)
+		if !isProduct {
+			writeError(w, http.StatusBadRequest, "'%s' does not match any of the served products", res.File)
+			return
+		}
+
+		rawTime, err := mapping.ParseProductTime(prod, file)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "%v", err)
+			return
+		}
+
+		resolution = idx.Resolve(rawTime)
+	case query.Has("time"):
+		timestamp, err := mapping.ParseTimestamp(query.Get("time"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "%v", err)
+			return
+		}
+		resolution = idx.ResolveTime(timestamp)
+	default:
+		writeError(w, http.StatusBadRequest, "one of 'path' or 'time' is required")
+		return
+	}
+
+	res.Time = resolution.Time
+	if !res.Time.Equal(resolution.RawTime) {
+		res.RawTime = &resolution.RawTime
+	}
+	if resolution.Snapped {
+		res.SnappedTo = &resolution.LookupTime
+	}
+	res.OnBoundary = resolution.OnBoundary
+	res.Stale = resolution.Stale
+	res.Unapproved = resolution.Unapproved
+	res.Fallback = resolution.Fallback
+
+	// A lookup without a matching config is answered with an empty config, as in the CLI's mapping
+	if timeInterval, kind, ok := resolution.Match(); ok {
+		interval := newIntervalResponse(timeInterval, kind, rewriter)
+		res.Config = interval.Config
+		res.Kind = kind
+		res.Interval = &interval
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) handleConfigs(w http.ResponseWriter, idx *siteindex.Index) {
+	res := configsResponse{Site: idx.Name, Sources: []sourceResponse{}}
+	rewriter := s.rewriter(idx)
+
+	for _, source := range idx.Precedence {
+		sourceRes := sourceResponse{Name: source.Name, Scheme: source.Scheme, Configs: []string{}}
+		for _, timeInterval := range source.Intervals {
+			sourceRes.Configs = append(sourceRes.Configs, rewriter.Output(timeInterval.Config))
+		}
+		res.Sources = append(res.Sources, sourceRes)
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
+
+func (s *Server) handleIntervals(w http.ResponseWriter, idx *siteindex.Index) {
+	res := intervalsResponse{Site: idx.Name, LoadedAt: idx.LoadedAt, Intervals: []intervalResponse{}}
+	rewriter := s.rewriter(idx)
+
+	for _, source := range idx.Precedence {
+		for _, timeInterval := range source.Intervals {
+			res.Intervals = append(res.Intervals, newIntervalResponse(timeInterval, source.Name, rewriter))
+		}
+	}
+
+	writeJSON(w, http.StatusOK, res)
+}
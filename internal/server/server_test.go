@@ -0,0 +1,329 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/pathmap"
+	"git.axiom/axiom/range-series-config-mapper/internal/product"
+	"git.axiom/axiom/range-series-config-mapper/internal/read"
+	"git.axiom/axiom/range-series-config-mapper/internal/settings"
+	"git.axiom/axiom/range-series-config-mapper/internal/siteindex"
+)
+
+// A site with two auto configs and one operator config
+var testSite = fstest.MapFS{
+	"Config_Auto/20230501T000000Z/Header.txt":                      {Data: []byte("MGS1 ! Site Code\n")},
+	"Config_Auto/20230520T120000Z/Header.txt":                      {Data: []byte("MGS1 ! Site Code\n")},
+	"Config_Operator/20230510T000000Z-20230515T000000Z/Header.txt": {Data: []byte("MGS1 ! Site Code\n")},
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	loaders := map[string]Loader{
+		"MGS1": func() (*siteindex.Index, error) {
+			return siteindex.Load(read.NewSite(testSite, "/archive/MGS1"), settings.Settings{})
+		},
+	}
+
+	srv, err := New(loaders, []product.Product{product.RangeSeries}, Paths{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	ts := httptest.NewServer(srv.Handler())
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestLookup(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Define test cases
+	tests := []struct {
+		name       string
+		query      string
+		wantStatus int
+		wantConfig string
+		wantKind   string
+	}{
+		{
+			name:       "By path during auto config",
+			query:      "?path=RangeSeries/2023/05/07/Rng_mgs1_2023_05_07_070610.rs",
+			wantStatus: http.StatusOK,
+			wantConfig: "/archive/MGS1/Config_Auto/20230501T000000Z",
+			wantKind:   "auto",
+		},
+		{
+			name:       "By time during operator config",
+			query:      "?time=2023-05-12T00:00:00Z",
+			wantStatus: http.StatusOK,
+			wantConfig: "/archive/MGS1/Config_Operator/20230510T000000Z-20230515T000000Z",
+			wantKind:   "operator",
+		},
+		{
+			name:       "Before any config",
+			query:      "?time=20230401T000000Z",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "Unknown product",
+			query:      "?path=Radials/RDLi_MGS1_2023_05_12_0000.ruv",
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Missing query",
+			query:      "",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute test
+			resp, err := http.Get(ts.URL + "/sites/MGS1/lookup" + tt.query)
+			if err != nil {
+				t.Fatalf("GET error = %v", err)
+			}
+			defer resp.Body.Close()
+
+			// Assert results
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %v, want %v", resp.StatusCode, tt.wantStatus)
+			}
+			if resp.StatusCode != http.StatusOK {
+				return
+			}
+
+			var got lookupResponse
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if got.Config != tt.wantConfig || got.Kind != tt.wantKind {
+				t.Errorf("lookup = %v (%v), want %v (%v)", got.Config, got.Kind, tt.wantConfig, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestLookupTimePolicy(t *testing.T) {
+	// Arrange
+	siteSettings := settings.Settings{
+		Fallback:         "following",
+		ClockCorrections: []settings.ClockCorrection{{Start: "20230101T000000Z", Offset: "240h"}},
+	}
+	loaders := map[string]Loader{
+		"MGS1": func() (*siteindex.Index, error) {
+			return siteindex.Load(read.NewSite(testSite, "/archive/MGS1"), siteSettings)
+		},
+	}
+
+	srv, err := New(loaders, []product.Product{product.RangeSeries}, Paths{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	// Define test cases
+	tests := []struct {
+		name         string
+		query        string
+		wantConfig   string
+		wantFallback string
+	}{
+		{"Clock correction is not applied", "?time=2023-05-12T00:00:00Z", "/archive/MGS1/Config_Operator/20230510T000000Z-20230515T000000Z", ""},
+		{"Fallback is applied", "?time=20230401T000000Z", "/archive/MGS1/Config_Auto/20230501T000000Z", "following"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute test
+			resp, err := http.Get(ts.URL + "/sites/MGS1/lookup" + tt.query)
+			if err != nil {
+				t.Fatalf("GET error = %v", err)
+			}
+			defer resp.Body.Close()
+
+			// Assert results
+			var got lookupResponse
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if got.Config != tt.wantConfig || got.Fallback != tt.wantFallback || got.RawTime != nil {
+				t.Errorf("lookup = %v (fallback %q, raw time %v), want %v (fallback %q)", got.Config, got.Fallback, got.RawTime, tt.wantConfig, tt.wantFallback)
+			}
+		})
+	}
+}
+
+func TestSiteEndpoints(t *testing.T) {
+	ts := newTestServer(t)
+
+	// Define test cases
+	tests := []struct {
+		path       string
+		wantStatus int
+	}{
+		{"/sites", http.StatusOK},
+		{"/sites/MGS1/configs", http.StatusOK},
+		{"/sites/MGS1/intervals", http.StatusOK},
+		{"/sites/MGS1/findings", http.StatusOK},
+		{"/sites/MGS1/unknown", http.StatusNotFound},
+		{"/sites/SCI1/intervals", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp, err := http.Get(ts.URL + tt.path)
+			if err != nil {
+				t.Fatalf("GET error = %v", err)
+			}
+			resp.Body.Close()
+
+			if resp.StatusCode != tt.wantStatus {
+				t.Errorf("status = %v, want %v", resp.StatusCode, tt.wantStatus)
+			}
+		})
+	}
+}
+
+func TestRescanKeepsIndexOnError(t *testing.T) {
+	// Arrange
+	fail := false
+	loaders := map[string]Loader{
+		"MGS1": func() (*siteindex.Index, error) {
+			if fail {
+				return nil, errors.New("site unavailable")
+			}
+			return siteindex.Load(read.NewSite(testSite, "/archive/MGS1"), settings.Settings{})
+		},
+	}
+
+	srv, err := New(loaders, []product.Product{product.RangeSeries}, Paths{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	before, _ := srv.index("MGS1")
+
+	// Execute test
+	fail = true
+	srv.Rescan()
+
+	// Assert results
+	if after, ok := srv.index("MGS1"); !ok || after != before {
+		t.Errorf("Rescan() replaced the index of a site that failed to load")
+	}
+}
+
+func TestLookupAfterLoad(t *testing.T) {
+	// Arrange
+	loaders := map[string]Loader{
+		"MGS1": func() (*siteindex.Index, error) {
+			idx, err := siteindex.Load(read.NewSite(testSite, "/archive/MGS1"), settings.Settings{})
+			if err != nil {
+				return nil, err
+			}
+
+			// Pretend the site was loaded long before the lookup, when the latest auto config ended
+			intervals := idx.Intervals("auto")
+			intervals[len(intervals)-1].End = time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)
+			idx.LoadedAt = intervals[len(intervals)-1].End
+			return idx, nil
+		},
+	}
+
+	srv, err := New(loaders, []product.Product{product.RangeSeries}, Paths{})
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	ts := httptest.NewServer(srv.Handler())
+	defer ts.Close()
+
+	// Define test cases
+	tests := []struct {
+		name  string
+		query string
+	}{
+		{"By path", "?path=RangeSeries/2023/07/01/Rng_mgs1_2023_07_01_000000.rs"},
+		{"By time", "?time=2023-07-01T00:00:00Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute test
+			resp, err := http.Get(ts.URL + "/sites/MGS1/lookup" + tt.query)
+			if err != nil {
+				t.Fatalf("GET error = %v", err)
+			}
+			defer resp.Body.Close()
+
+			// Assert results
+			var got lookupResponse
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if want := "/archive/MGS1/Config_Auto/20230520T120000Z"; got.Config != want {
+				t.Errorf("lookup = %v, want %v", got.Config, want)
+			}
+		})
+	}
+
+	// The shared index is left unchanged
+	idx, _ := srv.index("MGS1")
+	if intervals := idx.Intervals("auto"); !intervals[len(intervals)-1].End.Equal(idx.LoadedAt) {
+		t.Errorf("lookup changed the end of the loaded interval to %v", intervals[len(intervals)-1].End)
+	}
+}
+
+func TestLookupPaths(t *testing.T) {
+	// Arrange
+	loaders := map[string]Loader{
+		"MGS1": func() (*siteindex.Index, error) {
+			return siteindex.Load(read.NewSite(testSite, "/archive/MGS1"), settings.Settings{})
+		},
+	}
+
+	// Define test cases
+	tests := []struct {
+		name       string
+		paths      Paths
+		wantConfig string
+	}{
+		{"Path map", Paths{Rules: []pathmap.Rule{{From: "/archive", To: "/mnt/archive"}}}, "/mnt/archive/MGS1/Config_Auto/20230501T000000Z"},
+		{"Relative paths", Paths{Relative: true}, "Config_Auto/20230501T000000Z"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv, err := New(loaders, []product.Product{product.RangeSeries}, tt.paths)
+			if err != nil {
+				t.Fatalf("New() error = %v", err)
+			}
+			ts := httptest.NewServer(srv.Handler())
+			defer ts.Close()
+
+			// Execute test
+			resp, err := http.Get(ts.URL + "/sites/MGS1/lookup?path=/mnt/archive/MGS1/RangeSeries/2023/05/07/Rng_mgs1_2023_05_07_070610.rs")
+			if err != nil {
+				t.Fatalf("GET error = %v", err)
+			}
+			defer resp.Body.Close()
+
+			// Assert results
+			var got lookupResponse
+			if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+				t.Fatalf("decoding response: %v", err)
+			}
+			if got.Config != tt.wantConfig || got.Interval == nil || got.Interval.Config != tt.wantConfig {
+				t.Errorf("lookup = %+v, want config %v", got, tt.wantConfig)
+			}
+		})
+	}
+}
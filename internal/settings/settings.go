@@ -0,0 +1,321 @@
+package settings
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/exclude"
+	"git.axiom/axiom/range-series-config-mapper/internal/mapping"
+	"git.axiom/axiom/range-series-config-mapper/internal/product"
+	"git.axiom/axiom/range-series-config-mapper/internal/sitecode"
+)
+
+// Settings holds the per-site options that are too detailed to pass as CLI flags. They are read from a JSON file.
+type Settings struct {
+	// Files that must be present in every config, keyed by the name of the config source (e.g. `operator`)
+	RequiredFiles map[string][]string `json:"required_files"`
+	// Whether configs missing a required file are left out of the RangeSeries:Config mapping
+	ExcludeIncompleteConfigs bool `json:"exclude_incomplete_configs"`
+	// How RangeSeries files whose site code doesn't match the site directory are handled: `warn`, `skip` or `error`.
+	// The check is disabled when empty.
+	SiteCodePolicy string `json:"site_code_policy"`
+	// Whether site codes are also checked against the Header.txt of the matched config
+	CheckConfigSiteCode bool `json:"check_config_site_code"`
+	// Custom product definitions, selectable by name alongside the built-in products
+	Products []product.Product `json:"products"`
+	// Config sources in order of precedence, e.g. to add `Config_Reprocess` ahead of the operator configs, or to map
+	// with auto configs only. Defaults to the operator configs followed by the auto configs.
+	Precedence []Source `json:"precedence"`
+	// IANA timezone the site recorded file name timestamps in, e.g. `America/Los_Angeles`. Defaults to UTC.
+	Timezone string `json:"timezone"`
+	// Corrections for periods in which the site clock was known to be off
+	ClockCorrections []ClockCorrection `json:"clock_corrections"`
+	// How long before the start of a config interval a file may be stamped and still be mapped to it, e.g. `10s`
+	BoundaryGrace string `json:"boundary_grace"`
+	// How files outside every config interval are mapped: to the `preceding` or `following` config, or to the
+	// `default` config. Left unmapped if empty.
+	Fallback string `json:"fallback"`
+	// The config used by the `default` fallback
+	DefaultConfig string `json:"default_config"`
+	// How long after it starts an auto config stays valid, e.g. `720h`. Unlimited if empty.
+	MaxAutoValidity string `json:"max_auto_validity"`
+	// How files mapped to auto configs past their validity are handled: `flag` (the default) or `unmap`
+	StaleAutoPolicy string `json:"stale_auto_policy"`
+	// How much older the newest auto config may be than the newest mapped file before a warning, e.g. `168h`
+	StaleAutoWarning string `json:"stale_auto_warning"`
+	// Windows in which files must be mapped to operator configs, e.g. for deployments processed with approved configs
+	// only
+	OperatorWindows []OperatorWindow `json:"operator_windows"`
+	// How files resolved to auto configs within operator windows are handled: `warn` (the default) or `error`
+	OperatorWindowPolicy string `json:"operator_window_policy"`
+	// Glob patterns of the product files to include and exclude, e.g. `._*` or `*.partial`, in addition to those given
+	// on the command line
+	Include []string `json:"include"`
+	Exclude []string `json:"exclude"`
+	// File listing known-bad product files, each followed by the reason it is blocked
+	Blocklist string `json:"blocklist"`
+}
+
+// OperatorWindow is a window [start, end) in which files must be mapped to operator configs. Times are given in
+// RFC 3339 or as `20060102T150405Z`, and the end can be omitted.
+type OperatorWindow struct {
+	// Reported for files resolved to auto configs in the window, e.g. the name of the deployment
+	Name  string `json:"name"`
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// ClockCorrection shifts the timestamps recorded in [start, end) back by the offset the site clock was ahead. Times
+// are given in RFC 3339 or as `20060102T150405Z`, and the end can be omitted.
+type ClockCorrection struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+	// E.g. `90s`, or `-2m` if the clock was behind
+	Offset string `json:"offset"`
+}
+
+// Source is a directory of configs taking part in the precedence policy. The built-in `operator` and `auto` sources
+// can be given by name alone.
+type Source struct {
+	// Reported as the kind of the configs, e.g. `reprocess`
+	Name string `json:"name"`
+	// Directory of the configs within the site, e.g. `Config_Reprocess`
+	Dir string `json:"dir"`
+	// Naming scheme of the config directories, `auto` or `operator`
+	Scheme string `json:"scheme"`
+	// CSV or JSON manifest listing configs with their start and end times, read in addition to or instead of Dir
+	Manifest string `json:"manifest"`
+	// How many levels below Dir config directories are looked for, e.g. 2 for configs grouped by year. Defaults to its
+	// immediate children.
+	Depth int `json:"depth"`
+}
+
+// Load reads the settings file at path. An empty path yields the default settings.
+func Load(path string) (Settings, error) {
+	var res Settings
+	if path == "" {
+		return res, nil
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return Settings{}, err
+	}
+	defer file.Close()
+
+	// Reject unknown fields so that misspelled options don't silently fall back to defaults
+	decoder := json.NewDecoder(file)
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&res); err != nil {
+		return Settings{}, err
+	}
+
+	if err := res.validate(); err != nil {
+		return Settings{}, err
+	}
+
+	return res, nil
+}
+
+func (s Settings) validate() error {
+	if s.SiteCodePolicy != "" && !slices.Contains(sitecode.Policies, s.SiteCodePolicy) {
+		return fmt.Errorf("invalid site_code_policy '%s', supported values are %v", s.SiteCodePolicy, sitecode.Policies)
+	}
+
+	for _, prod := range s.Products {
+		if err := prod.Validate(); err != nil {
+			return err
+		}
+	}
+
+	if _, err := s.Policy(); err != nil {
+		return err
+	}
+	if _, err := exclude.New(s.Include, s.Exclude, nil); err != nil {
+		return err
+	}
+
+	names := make(map[string]bool)
+	for _, source := range s.Precedence {
+		if err := source.validate(); err != nil {
+			return err
+		}
+		if names[source.Name] {
+			return fmt.Errorf("config source '%s' is listed more than once in the precedence", source.Name)
+		}
+		names[source.Name] = true
+	}
+
+	if len(s.Precedence) == 0 {
+		names = map[string]bool{mapping.ConfigKindOperator: true, mapping.ConfigKindAuto: true}
+	}
+	for name := range s.RequiredFiles {
+		if !names[name] {
+			return fmt.Errorf("required_files are given for unknown config source '%s', expected the name of a source such as '%s'", name, mapping.ConfigKindAuto)
+		}
+	}
+
+	return nil
+}
+
+// Policy returns the site's rules for resolving file timestamps to configs
+func (s Settings) Policy() (mapping.Policy, error) {
+	var res mapping.Policy
+	var err error
+
+	if res.Clock, err = s.ClockCorrection(); err != nil {
+		return mapping.Policy{}, err
+	}
+
+	if s.BoundaryGrace != "" {
+		if res.BoundaryGrace, err = time.ParseDuration(s.BoundaryGrace); err != nil || res.BoundaryGrace <= 0 {
+			return mapping.Policy{}, fmt.Errorf("invalid boundary_grace '%s', expected a positive duration such as 10s", s.BoundaryGrace)
+		}
+	}
+
+	if s.Fallback != "" && !slices.Contains(mapping.Fallbacks, s.Fallback) {
+		return mapping.Policy{}, fmt.Errorf("invalid fallback '%s', supported values are %v", s.Fallback, mapping.Fallbacks)
+	}
+	if s.Fallback == mapping.FallbackDefault && s.DefaultConfig == "" {
+		return mapping.Policy{}, fmt.Errorf("the default fallback requires a default_config")
+	}
+	res.Fallback, res.DefaultConfig = s.Fallback, s.DefaultConfig
+
+	if s.MaxAutoValidity != "" {
+		if res.MaxAutoValidity, err = time.ParseDuration(s.MaxAutoValidity); err != nil || res.MaxAutoValidity <= 0 {
+			return mapping.Policy{}, fmt.Errorf("invalid max_auto_validity '%s', expected a positive duration such as 720h", s.MaxAutoValidity)
+		}
+	}
+	if s.StaleAutoPolicy != "" && !slices.Contains(mapping.StalePolicies, s.StaleAutoPolicy) {
+		return mapping.Policy{}, fmt.Errorf("invalid stale_auto_policy '%s', supported values are %v", s.StaleAutoPolicy, mapping.StalePolicies)
+	}
+	res.Stale = s.StaleAutoPolicy
+
+	if s.StaleAutoWarning != "" {
+		if res.StaleAutoWarning, err = time.ParseDuration(s.StaleAutoWarning); err != nil || res.StaleAutoWarning <= 0 {
+			return mapping.Policy{}, fmt.Errorf("invalid stale_auto_warning '%s', expected a positive duration such as 168h", s.StaleAutoWarning)
+		}
+	}
+
+	for i, operatorWindow := range s.OperatorWindows {
+		window, err := operatorWindow.window(i)
+		if err != nil {
+			return mapping.Policy{}, err
+		}
+		res.OperatorWindows = append(res.OperatorWindows, window)
+	}
+	if s.OperatorWindowPolicy != "" && !slices.Contains(mapping.UnapprovedPolicies, s.OperatorWindowPolicy) {
+		return mapping.Policy{}, fmt.Errorf("invalid operator_window_policy '%s', supported values are %v", s.OperatorWindowPolicy, mapping.UnapprovedPolicies)
+	}
+	res.Unapproved = s.OperatorWindowPolicy
+
+	return res, nil
+}
+
+// Exclusions returns the rules deciding which product files take part in a run, combining the site's patterns with
+// those given on the command line, and reading the site's blocklist
+func (s Settings) Exclusions(includes []string, excludes []string) (exclude.Rules, error) {
+	var blocklist map[string]string
+	if s.Blocklist != "" {
+		var err error
+		if blocklist, err = exclude.ReadBlocklist(s.Blocklist); err != nil {
+			return exclude.Rules{}, err
+		}
+	}
+
+	return exclude.New(append(slices.Clone(s.Include), includes...), append(slices.Clone(s.Exclude), excludes...), blocklist)
+}
+
+// ClockCorrection returns the correction of file name timestamps for the site's timezone and clock corrections
+func (s Settings) ClockCorrection() (mapping.ClockCorrection, error) {
+	var res mapping.ClockCorrection
+
+	if s.Timezone != "" {
+		location, err := time.LoadLocation(s.Timezone)
+		if err != nil {
+			return mapping.ClockCorrection{}, fmt.Errorf("invalid timezone '%s': %v", s.Timezone, err)
+		}
+		res.Location = location
+	}
+
+	for _, correction := range s.ClockCorrections {
+		rule, err := correction.rule()
+		if err != nil {
+			return mapping.ClockCorrection{}, err
+		}
+		res.Rules = append(res.Rules, rule)
+	}
+
+	return res, nil
+}
+
+func (c ClockCorrection) rule() (mapping.ClockRule, error) {
+	var res mapping.ClockRule
+	var err error
+
+	if res.Start, err = mapping.ParseTimestamp(c.Start); err != nil {
+		return mapping.ClockRule{}, fmt.Errorf("invalid clock correction start: %v", err)
+	}
+	if c.End != "" {
+		if res.End, err = mapping.ParseTimestamp(c.End); err != nil {
+			return mapping.ClockRule{}, fmt.Errorf("invalid clock correction end: %v", err)
+		}
+		if !res.Start.Before(res.End) {
+			return mapping.ClockRule{}, fmt.Errorf("clock correction starting %s ends before it starts", c.Start)
+		}
+	}
+	if res.Offset, err = time.ParseDuration(c.Offset); err != nil {
+		return mapping.ClockRule{}, fmt.Errorf("invalid clock correction offset '%s': %v", c.Offset, err)
+	}
+
+	return res, nil
+}
+
+// window parses the operator window, naming it by its position if it has no name
+func (w OperatorWindow) window(i int) (mapping.OperatorWindow, error) {
+	res := mapping.OperatorWindow{Name: w.Name}
+	var err error
+
+	if res.Name == "" {
+		res.Name = fmt.Sprintf("#%d", i+1)
+	}
+	if res.Start, err = mapping.ParseTimestamp(w.Start); err != nil {
+		return mapping.OperatorWindow{}, fmt.Errorf("invalid start of operator window '%s': %v", res.Name, err)
+	}
+	if w.End != "" {
+		if res.End, err = mapping.ParseTimestamp(w.End); err != nil {
+			return mapping.OperatorWindow{}, fmt.Errorf("invalid end of operator window '%s': %v", res.Name, err)
+		}
+		if !res.Start.Before(res.End) {
+			return mapping.OperatorWindow{}, fmt.Errorf("operator window '%s' ends before it starts", res.Name)
+		}
+	}
+
+	return res, nil
+}
+
+func (s Source) validate() error {
+	if s.Name == "" {
+		return fmt.Errorf("config sources in the precedence must have a name")
+	}
+	if s.Scheme != "" && !slices.Contains(mapping.Schemes, s.Scheme) {
+		return fmt.Errorf("invalid scheme '%s' of config source '%s', supported values are %v", s.Scheme, s.Name, mapping.Schemes)
+	}
+
+	if s.Depth < 0 {
+		return fmt.Errorf("invalid depth %d of config source '%s', expected a non-negative number of levels", s.Depth, s.Name)
+	}
+
+	isBuiltin := s.Name == mapping.ConfigKindAuto || s.Name == mapping.ConfigKindOperator
+	if !isBuiltin && s.Dir == "" && s.Manifest == "" {
+		return fmt.Errorf("config source '%s' must have a dir or a manifest", s.Name)
+	}
+	if !isBuiltin && s.Dir != "" && s.Scheme == "" {
+		return fmt.Errorf("config source '%s' must have a scheme for its dir", s.Name)
+	}
+
+	return nil
+}
@@ -0,0 +1,168 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLoad(t *testing.T) {
+	// Define test cases
+	tests := []struct {
+		name     string
+		contents string
+		want     Settings
+		wantErr  bool
+	}{
+		{
+			name:     "Required files",
+			contents: `{"required_files": {"operator": ["Header.txt"]}, "exclude_incomplete_configs": true}`,
+			want: Settings{
+				RequiredFiles:            map[string][]string{"operator": {"Header.txt"}},
+				ExcludeIncompleteConfigs: true,
+			},
+			wantErr: false,
+		},
+		{
+			name:     "Required files keyed by directory",
+			contents: `{"required_files": {"Config_Operator": ["Header.txt"]}}`,
+			want:     Settings{},
+			wantErr:  true,
+		},
+		{
+			name:     "Required files of a custom source",
+			contents: `{"required_files": {"reprocess": ["Header.txt"]}, "precedence": [{"name": "reprocess", "manifest": "reprocess.csv"}, {"name": "auto"}]}`,
+			want: Settings{
+				RequiredFiles: map[string][]string{"reprocess": {"Header.txt"}},
+				Precedence:    []Source{{Name: "reprocess", Manifest: "reprocess.csv"}, {Name: "auto"}},
+			},
+			wantErr: false,
+		},
+		{
+			name:     "Precedence",
+			contents: `{"precedence": [{"name": "reprocess", "dir": "Config_Reprocess", "scheme": "operator"}, {"name": "operator"}]}`,
+			want: Settings{
+				Precedence: []Source{{Name: "reprocess", Dir: "Config_Reprocess", Scheme: "operator"}, {Name: "operator"}},
+			},
+			wantErr: false,
+		},
+		{
+			name:     "Precedence source without dir",
+			contents: `{"precedence": [{"name": "reprocess", "scheme": "operator"}]}`,
+			want:     Settings{},
+			wantErr:  true,
+		},
+		{
+			name:     "Precedence source listed twice",
+			contents: `{"precedence": [{"name": "auto"}, {"name": "auto"}]}`,
+			want:     Settings{},
+			wantErr:  true,
+		},
+		{
+			name:     "Timezone and clock corrections",
+			contents: `{"timezone": "America/Los_Angeles", "clock_corrections": [{"start": "2023-03-01T00:00:00Z", "end": "20230601T000000Z", "offset": "90s"}]}`,
+			want: Settings{
+				Timezone:         "America/Los_Angeles",
+				ClockCorrections: []ClockCorrection{{Start: "2023-03-01T00:00:00Z", End: "20230601T000000Z", Offset: "90s"}},
+			},
+			wantErr: false,
+		},
+		{
+			name:     "Unknown timezone",
+			contents: `{"timezone": "America/Santa_Barbara"}`,
+			want:     Settings{},
+			wantErr:  true,
+		},
+		{
+			name:     "Clock correction without offset",
+			contents: `{"clock_corrections": [{"start": "2023-03-01T00:00:00Z"}]}`,
+			want:     Settings{},
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid boundary grace",
+			contents: `{"boundary_grace": "10"}`,
+			want:     Settings{},
+			wantErr:  true,
+		},
+		{
+			name:     "Negative source depth",
+			contents: `{"precedence": [{"name": "auto", "depth": -1}]}`,
+			want:     Settings{},
+			wantErr:  true,
+		},
+		{
+			name:     "Zero source depth",
+			contents: `{"precedence": [{"name": "auto", "depth": 0}]}`,
+			want:     Settings{Precedence: []Source{{Name: "auto"}}},
+			wantErr:  false,
+		},
+		{
+			name:     "Zero boundary grace",
+			contents: `{"boundary_grace": "0s"}`,
+			want:     Settings{},
+			wantErr:  true,
+		},
+		{
+			name:     "Default fallback without config",
+			contents: `{"fallback": "default"}`,
+			want:     Settings{},
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid stale auto policy",
+			contents: `{"max_auto_validity": "720h", "stale_auto_policy": "drop"}`,
+			want:     Settings{},
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid max auto validity",
+			contents: `{"max_auto_validity": "-1h"}`,
+			want:     Settings{},
+			wantErr:  true,
+		},
+		{
+			name:     "Operator window ending before it starts",
+			contents: `{"operator_windows": [{"name": "deployment", "start": "20230601T000000Z", "end": "20230501T000000Z"}]}`,
+			want:     Settings{},
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid operator window policy",
+			contents: `{"operator_window_policy": "skip"}`,
+			want:     Settings{},
+			wantErr:  true,
+		},
+		{
+			name:     "Invalid exclude pattern",
+			contents: `{"exclude": ["[._*"]}`,
+			want:     Settings{},
+			wantErr:  true,
+		},
+		{
+			name:     "Unknown field",
+			contents: `{"required_file": {"Config_Operator": ["Header.txt"]}}`,
+			want:     Settings{},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "settings.json")
+			if err := os.WriteFile(path, []byte(tt.contents), 0644); err != nil {
+				t.Fatalf("Failed to write settings file: %v", err)
+			}
+
+			got, err := Load(path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Load() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Load() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
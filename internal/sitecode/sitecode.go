@@ -0,0 +1,141 @@
+package sitecode
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/read"
+)
+
+const (
+	PolicyWarn  = "warn"
+	PolicySkip  = "skip"
+	PolicyError = "error"
+)
+
+var Policies = []string{PolicyWarn, PolicySkip, PolicyError}
+
+const (
+	SourceSiteDir      = "site-dir"
+	SourceConfigHeader = "config-header"
+)
+
+const headerFileName = "Header.txt"
+
+// Matches the site code between the file type prefix and the timestamp, e.g. `mgs1` in `Rng_mgs1_2023_05_17_070610.rs`
+// or `MGS1` in `CSS_MGS1_23_05_17_0700.cs4`
+var fileNameSiteCodeRegex = regexp.MustCompile(`^[A-Za-z]+_([A-Za-z0-9]*[A-Za-z][A-Za-z0-9]*)_\d{2}`)
+
+type Mismatch struct {
+	File     string
+	Config   string
+	Expected string
+	Found    string
+	Source   string
+}
+
+func (m Mismatch) String() string {
+	if m.Source == SourceConfigHeader {
+		return fmt.Sprintf("site code '%s' of %s not found in %s of config %s", m.Found, m.File, headerFileName, m.Config)
+	}
+	if m.Found == "" {
+		return fmt.Sprintf("no site code found in the name of %s, expected '%s'", m.File, m.Expected)
+	}
+	return fmt.Sprintf("site code '%s' of %s does not match site directory '%s'", m.Found, m.File, m.Expected)
+}
+
+// FromFileName extracts the site code from a SeaSonde file name
+func FromFileName(fileName string) (string, error) {
+	matches := fileNameSiteCodeRegex.FindStringSubmatch(filepath.Base(fileName))
+	if len(matches) != 2 {
+		return "", fmt.Errorf("no site code found in '%s'", fileName)
+	}
+
+	return matches[1], nil
+}
+
+// headerContains checks whether the config's Header.txt contains the site code as a value. The second return value is
+// false when the config has no Header.txt to check against.
+func headerContains(fsys fs.FS, configDir string, siteCode string) (bool, bool, error) {
+	file, err := fsys.Open(path.Join(configDir, headerFileName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return false, false, nil
+	} else if err != nil {
+		return false, false, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// Ignore the descriptive label following `!`
+		values, _, _ := strings.Cut(scanner.Text(), "!")
+		if slices.ContainsFunc(strings.Fields(values), func(value string) bool {
+			return strings.EqualFold(value, siteCode)
+		}) {
+			return true, true, nil
+		}
+	}
+
+	return false, true, scanner.Err()
+}
+
+// Check compares the site code of each mapped file against the expected site code and, optionally, against the
+// Header.txt of the config it was mapped to. Comparisons are case-insensitive.
+func Check(site read.Site, rangeSeriesToConfig map[string]string, expectedSiteCode string, checkConfigHeader bool) ([]Mismatch, error) {
+	var mismatches []Mismatch
+
+	// Header checks are cached per config and site code, since many files share a config
+	type headerKey struct{ config, siteCode string }
+	headerResults := make(map[headerKey]bool)
+
+	files := make([]string, 0, len(rangeSeriesToConfig))
+	for file := range rangeSeriesToConfig {
+		files = append(files, file)
+	}
+	slices.Sort(files)
+
+	for _, file := range files {
+		config := rangeSeriesToConfig[file]
+
+		siteCode, err := FromFileName(file)
+		if err != nil || !strings.EqualFold(siteCode, expectedSiteCode) {
+			mismatches = append(mismatches, Mismatch{File: file, Config: config, Expected: expectedSiteCode, Found: siteCode, Source: SourceSiteDir})
+			continue
+		}
+
+		if !checkConfigHeader || config == "" {
+			continue
+		}
+
+		key := headerKey{config, strings.ToLower(siteCode)}
+		found, ok := headerResults[key]
+		if !ok {
+			configDir, err := site.Rel(config)
+			if err != nil {
+				return nil, err
+			}
+
+			var hasHeader bool
+			found, hasHeader, err = headerContains(site.FS, configDir, siteCode)
+			if err != nil {
+				return nil, err
+			}
+			// A missing header can't contradict the site code
+			found = found || !hasHeader
+			headerResults[key] = found
+		}
+
+		if !found {
+			mismatches = append(mismatches, Mismatch{File: file, Config: config, Expected: expectedSiteCode, Found: siteCode, Source: SourceConfigHeader})
+		}
+	}
+
+	return mismatches, nil
+}
@@ -0,0 +1,69 @@
+package sitecode
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/read"
+)
+
+func TestFromFileName(t *testing.T) {
+	// Define test cases
+	tests := []struct {
+		name     string
+		fileName string
+		want     string
+		wantErr  bool
+	}{
+		{"RangeSeries file", "/data/MGS1/RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610.rs", "mgs1", false},
+		{"Cross spectra file", "CSS_SCI1_2023_05_17_070610.cs4", "SCI1", false},
+		{"No site code", "Rng_2023_05_17_070610.rs", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromFileName(tt.fileName)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("FromFileName() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if got != tt.want {
+				t.Errorf("FromFileName() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheck(t *testing.T) {
+	// Arrange
+	site := read.NewSite(fstest.MapFS{
+		"Config_Auto/20230501T000000Z/Header.txt": {Data: []byte("MGS1 ! Site Code\n")},
+		"Config_Auto/20230518T000000Z/Header.txt": {Data: []byte("SCI1 ! Site Code\n")},
+	}, "/archive/MGS1")
+	matchingConfig := "/archive/MGS1/Config_Auto/20230501T000000Z"
+	otherConfig := "/archive/MGS1/Config_Auto/20230518T000000Z"
+
+	rangeSeriesToConfig := map[string]string{
+		"Rng_mgs1_2023_05_17_070610.rs": matchingConfig,
+		"Rng_mgs1_2023_05_18_070610.rs": otherConfig,
+		"Rng_sci1_2023_05_17_070610.rs": matchingConfig,
+		"Rng_mgs1_2023_05_19_070610.rs": "",
+	}
+
+	expected := []Mismatch{
+		{File: "Rng_mgs1_2023_05_18_070610.rs", Config: otherConfig, Expected: "MGS1", Found: "mgs1", Source: SourceConfigHeader},
+		{File: "Rng_sci1_2023_05_17_070610.rs", Config: matchingConfig, Expected: "MGS1", Found: "sci1", Source: SourceSiteDir},
+	}
+
+	// Execute test
+	got, err := Check(site, rangeSeriesToConfig, "MGS1", true)
+	if err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+
+	// Assert results
+	if !reflect.DeepEqual(got, expected) {
+		t.Errorf("Check() = %v, want %v", got, expected)
+	}
+}
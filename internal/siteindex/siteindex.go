@@ -0,0 +1,227 @@
+package siteindex
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/config_interval"
+	"git.axiom/axiom/range-series-config-mapper/internal/configsource"
+	"git.axiom/axiom/range-series-config-mapper/internal/logger"
+	"git.axiom/axiom/range-series-config-mapper/internal/mapping"
+	"git.axiom/axiom/range-series-config-mapper/internal/read"
+	"git.axiom/axiom/range-series-config-mapper/internal/settings"
+)
+
+const (
+	AutoConfigDir     = "Config_Auto"
+	OperatorConfigDir = "Config_Operator"
+)
+
+const (
+	SeverityWarning = "warning"
+	SeverityError   = "error"
+)
+
+// Finding is a problem with a site's configs discovered while loading them
+type Finding struct {
+	Severity string `json:"severity"`
+	Message  string `json:"message"`
+}
+
+// Index holds the config intervals of a site, from which the config for any timestamp can be resolved
+type Index struct {
+	Name     string
+	Site     read.Site
+	Settings settings.Settings
+	// The config intervals of each source, in order of precedence
+	Precedence mapping.Precedence
+	// The rules for resolving file timestamps to configs
+	Policy   mapping.Policy
+	Findings []Finding
+	LoadedAt time.Time
+}
+
+// SiteName returns the name of a site, e.g. `MGS1`, from its directory
+func SiteName(siteDir string) string {
+	siteDir, _ = strings.CutSuffix(siteDir, "!")
+	return filepath.Base(filepath.Clean(siteDir))
+}
+
+// ConfigSources returns the site's config sources in order of precedence, filling in the directories and naming schemes
+// of the built-in sources. Built-in sources given a manifest are only read from their directory if it is set explicitly.
+func ConfigSources(siteSettings settings.Settings) []settings.Source {
+	builtins := map[string]settings.Source{
+		mapping.ConfigKindOperator: {Name: mapping.ConfigKindOperator, Dir: OperatorConfigDir, Scheme: mapping.SchemeOperator},
+		mapping.ConfigKindAuto:     {Name: mapping.ConfigKindAuto, Dir: AutoConfigDir, Scheme: mapping.SchemeAuto},
+	}
+
+	if len(siteSettings.Precedence) == 0 {
+		return []settings.Source{builtins[mapping.ConfigKindOperator], builtins[mapping.ConfigKindAuto]}
+	}
+
+	res := make([]settings.Source, len(siteSettings.Precedence))
+	for i, source := range siteSettings.Precedence {
+		if builtin, ok := builtins[source.Name]; ok {
+			if source.Dir == "" && source.Manifest == "" {
+				source.Dir = builtin.Dir
+			}
+			if source.Scheme == "" {
+				source.Scheme = builtin.Scheme
+			}
+		}
+		res[i] = source
+	}
+
+	return res
+}
+
+// configSource returns the provider of a source's config intervals: its directory, its manifest or both combined
+func configSource(site read.Site, source settings.Source) configsource.ConfigSource {
+	var res configsource.Composite
+	if source.Dir != "" {
+		res = append(res, &configsource.Directory{Site: site, Dir: source.Dir, Scheme: source.Scheme, Depth: source.Depth})
+	}
+	if source.Manifest != "" {
+		res = append(res, configsource.Manifest{Site: site, Path: source.Manifest})
+	}
+
+	if len(res) == 1 {
+		return res[0]
+	}
+	return res
+}
+
+// findIncompleteConfigs reports the configs that are missing any of the files required for their source
+func findIncompleteConfigs(site read.Site, configs []string, sourceName string, siteSettings settings.Settings) ([]string, []Finding, error) {
+	var incompleteConfigs []string
+	var findings []Finding
+
+	requiredFiles := siteSettings.RequiredFiles[sourceName]
+	if len(requiredFiles) == 0 {
+		return incompleteConfigs, findings, nil
+	}
+
+	for _, config := range configs {
+		configDir, err := site.Rel(config)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		missingFiles, err := read.FindMissingFiles(site.FS, configDir, requiredFiles)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error checking required files of %v: %v", config, err)
+		}
+
+		if len(missingFiles) > 0 {
+			findings = append(findings, Finding{
+				Severity: SeverityWarning,
+				Message:  fmt.Sprintf("%s config %v is missing required files: %v", sourceName, config, strings.Join(missingFiles, ", ")),
+			})
+			incompleteConfigs = append(incompleteConfigs, config)
+		}
+	}
+
+	return incompleteConfigs, findings, nil
+}
+
+// Load discovers the site's configs and builds their intervals. Problems with the configs themselves are reported as
+// findings rather than errors, so callers can decide whether to proceed.
+func Load(site read.Site, siteSettings settings.Settings) (*Index, error) {
+	res := &Index{
+		Name:     SiteName(site.Root),
+		Site:     site,
+		Settings: siteSettings,
+		Findings: []Finding{},
+		LoadedAt: time.Now().UTC(),
+	}
+
+	policy, err := siteSettings.Policy()
+	if err != nil {
+		return nil, err
+	}
+	res.Policy = policy
+
+	var incompleteConfigs []string
+	validationLogger := &logger.RecordingLogger{}
+	for _, source := range ConfigSources(siteSettings) {
+		// Retrieve configs and build mapping of time intervals to configs
+		provider := configSource(site, source)
+		timeIntervals, err := provider.Intervals()
+		if err != nil {
+			return nil, err
+		}
+
+		// Report ignored config directories and configs sharing a start time
+		if checker, ok := provider.(configsource.Checker); ok {
+			problems, err := checker.Check()
+			if err != nil {
+				return nil, err
+			}
+			for _, problem := range problems {
+				res.Findings = append(res.Findings, Finding{Severity: SeverityWarning, Message: fmt.Sprintf("%s configs: %s", source.Name, problem)})
+			}
+		}
+		res.Precedence = append(res.Precedence, mapping.Source{Name: source.Name, Scheme: source.Scheme, Intervals: timeIntervals})
+
+		configs := make([]string, len(timeIntervals))
+		for i, timeInterval := range timeIntervals {
+			configs[i] = timeInterval.Config
+		}
+
+		// Check configs for required files
+		incompleteSourceConfigs, findings, err := findIncompleteConfigs(site, configs, source.Name, siteSettings)
+		if err != nil {
+			return nil, err
+		}
+		res.Findings = append(res.Findings, findings...)
+		incompleteConfigs = append(incompleteConfigs, incompleteSourceConfigs...)
+
+		// Validate configs
+		if source.Scheme == mapping.SchemeOperator {
+			mapping.ValidateOperatorConfigs(timeIntervals, validationLogger)
+		}
+	}
+	for _, msg := range validationLogger.Logs {
+		res.Findings = append(res.Findings, Finding{Severity: SeverityError, Message: strings.TrimPrefix(msg, "Error: ")})
+	}
+
+	// Incomplete configs are excluded after the intervals are built, so that their time spans map to no config
+	// rather than being absorbed by the preceding auto config
+	if len(incompleteConfigs) > 0 {
+		if siteSettings.ExcludeIncompleteConfigs {
+			log.Printf("Excluding %d incomplete config(s) from the mapping\n", len(incompleteConfigs))
+			for i, source := range res.Precedence {
+				res.Precedence[i].Intervals = mapping.ExcludeConfigs(source.Intervals, incompleteConfigs)
+				res.Precedence[i].Excluded = slices.DeleteFunc(slices.Clone(source.Intervals), func(timeInterval config_interval.ConfigInterval) bool {
+					return !slices.Contains(incompleteConfigs, timeInterval.Config)
+				})
+			}
+		} else {
+			log.Printf("Warning: %d incomplete config(s) found, they will still be mapped\n", len(incompleteConfigs))
+		}
+	}
+
+	return res, nil
+}
+
+// Resolve looks up the config of a file from the timestamp in its name, according to the site's policy. Open-ended
+// intervals are extended to the time of the lookup, so that files stamped after the index was loaded still match.
+func (idx *Index) Resolve(rawTime time.Time) mapping.Resolution {
+	return idx.Precedence.Extended().Resolve(rawTime, idx.Policy)
+}
+
+// ResolveTime looks up the config in effect at a timestamp in true time, according to the site's policy without its
+// clock correction. Open-ended intervals are extended as for Resolve.
+func (idx *Index) ResolveTime(timestamp time.Time) mapping.Resolution {
+	return idx.Precedence.Extended().ResolveTime(timestamp, idx.Policy)
+}
+
+// Intervals returns the config intervals of the named source, e.g. `auto`
+func (idx *Index) Intervals(name string) []config_interval.ConfigInterval {
+	source, _ := idx.Precedence.Source(name)
+	return source.Intervals
+}
@@ -0,0 +1,182 @@
+package siteindex
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/mapping"
+	"git.axiom/axiom/range-series-config-mapper/internal/read"
+	"git.axiom/axiom/range-series-config-mapper/internal/settings"
+)
+
+func TestLoad(t *testing.T) {
+	// Arrange
+	site := read.NewSite(fstest.MapFS{
+		"Config_Auto/20230501T000000Z/Header.txt":                      {Data: []byte("MGS1 ! Site Code\n")},
+		"Config_Auto/20230520T120000Z/AnalysisOptions.txt":             {Data: []byte("1 ! Option\n")},
+		"Config_Operator/20230510T000000Z-20230515T000000Z/Header.txt": {Data: []byte("MGS1 ! Site Code\n")},
+		"Config_Operator/20230514T000000Z-20230516T000000Z/Header.txt": {Data: []byte("MGS1 ! Site Code\n")},
+		"RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610.rs":         {},
+	}, "/archive/UCSB/MGS1")
+
+	siteSettings := settings.Settings{
+		RequiredFiles:            map[string][]string{mapping.ConfigKindAuto: {"Header.txt"}},
+		ExcludeIncompleteConfigs: true,
+	}
+
+	// Execute test
+	got, err := Load(site, siteSettings)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Assert results
+	if got.Name != "MGS1" {
+		t.Errorf("Load() name = %v, want MGS1", got.Name)
+	}
+
+	// The incomplete auto config is excluded, and the overlapping operator configs are reported as an error
+	autoConfigIntervals, operatorConfigIntervals := got.Intervals(mapping.ConfigKindAuto), got.Intervals(mapping.ConfigKindOperator)
+	if len(autoConfigIntervals) != 1 || autoConfigIntervals[0].Config != "/archive/UCSB/MGS1/Config_Auto/20230501T000000Z" {
+		t.Errorf("Load() auto config intervals = %v", autoConfigIntervals)
+	}
+	if len(operatorConfigIntervals) != 2 {
+		t.Errorf("Load() operator config intervals = %v", operatorConfigIntervals)
+	}
+
+	wantSeverities := []string{SeverityWarning, SeverityError}
+	if len(got.Findings) != len(wantSeverities) {
+		t.Fatalf("Load() findings = %v, want %d findings", got.Findings, len(wantSeverities))
+	}
+	for i, severity := range wantSeverities {
+		if got.Findings[i].Severity != severity {
+			t.Errorf("Load() finding %d = %v, want severity %v", i, got.Findings[i], severity)
+		}
+	}
+
+	// Files stamped during the incomplete auto config's time span map to no config
+	if _, _, ok := got.ResolveTime(time.Date(2023, 5, 21, 0, 0, 0, 0, time.UTC)).Match(); ok {
+		t.Errorf("ResolveTime() found a config within the excluded config's interval")
+	}
+}
+
+func TestLoadExcludedConfigFallback(t *testing.T) {
+	// Arrange
+	site := read.NewSite(fstest.MapFS{
+		"Config_Auto/20230501T000000Z/Header.txt":          {Data: []byte("MGS1 ! Site Code\n")},
+		"Config_Auto/20230510T000000Z/AnalysisOptions.txt": {Data: []byte("1 ! Option\n")},
+		"Config_Auto/20230520T000000Z/Header.txt":          {Data: []byte("MGS1 ! Site Code\n")},
+	}, "/archive/UCSB/MGS1")
+
+	siteSettings := settings.Settings{
+		RequiredFiles:            map[string][]string{mapping.ConfigKindAuto: {"Header.txt"}},
+		ExcludeIncompleteConfigs: true,
+		Fallback:                 mapping.FallbackPreceding,
+		Precedence:               []settings.Source{{Name: mapping.ConfigKindAuto}},
+	}
+
+	// Execute test
+	got, err := Load(site, siteSettings)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+
+	// Assert results, the span of the incomplete config is not mapped to the preceding config by the fallback
+	if resolution := got.Resolve(time.Date(2023, 5, 15, 0, 0, 0, 0, time.UTC)); resolution.Config() != "" {
+		t.Errorf("Resolve() = %v, want no config within the excluded config's interval", resolution.Config())
+	}
+	if resolution := got.Resolve(time.Date(2023, 5, 25, 0, 0, 0, 0, time.UTC)); resolution.Config() != "/archive/UCSB/MGS1/Config_Auto/20230520T000000Z" {
+		t.Errorf("Resolve() = %v, want the latest config after the excluded config's interval", resolution.Config())
+	}
+}
+
+func TestLoadInvalidConfigName(t *testing.T) {
+	// Arrange
+	site := read.NewSite(fstest.MapFS{
+		"Config_Operator/20230101T000000Z/Header.txt": {Data: []byte("MGS1 ! Site Code\n")},
+	}, "/archive/UCSB/MGS1")
+
+	// Execute test
+	_, err := Load(site, settings.Settings{})
+
+	// Assert results
+	if err == nil {
+		t.Errorf("Load() error = nil, want an error for the operator config without an end time")
+	}
+}
+
+func TestLoadPrecedence(t *testing.T) {
+	// Arrange
+	site := read.NewSite(fstest.MapFS{
+		"Config_Auto/20230501T000000Z/Header.txt":                       {Data: []byte("MGS1 ! Site Code\n")},
+		"Config_Operator/20230510T000000Z-20230515T000000Z/Header.txt":  {Data: []byte("MGS1 ! Site Code\n")},
+		"Config_Reprocess/20230512T000000Z-20230518T000000Z/Header.txt": {Data: []byte("MGS1 ! Site Code\n")},
+	}, "/archive/UCSB/MGS1")
+
+	// Define test cases
+	tests := []struct {
+		name       string
+		precedence []settings.Source
+		wantConfig string
+		wantKind   string
+	}{
+		{"Default", nil, "/archive/UCSB/MGS1/Config_Operator/20230510T000000Z-20230515T000000Z", mapping.ConfigKindOperator},
+		{"Reprocess first", []settings.Source{{Name: "reprocess", Dir: "Config_Reprocess", Scheme: mapping.SchemeOperator}, {Name: mapping.ConfigKindOperator}, {Name: mapping.ConfigKindAuto}}, "/archive/UCSB/MGS1/Config_Reprocess/20230512T000000Z-20230518T000000Z", "reprocess"},
+		{"Auto only", []settings.Source{{Name: mapping.ConfigKindAuto}}, "/archive/UCSB/MGS1/Config_Auto/20230501T000000Z", mapping.ConfigKindAuto},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute test
+			got, err := Load(site, settings.Settings{Precedence: tt.precedence})
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+
+			// Assert results
+			timeInterval, kind, _ := got.ResolveTime(time.Date(2023, 5, 13, 0, 0, 0, 0, time.UTC)).Match()
+			if timeInterval.Config != tt.wantConfig || kind != tt.wantKind {
+				t.Errorf("ResolveTime() = %v (%v), want %v (%v)", timeInterval.Config, kind, tt.wantConfig, tt.wantKind)
+			}
+		})
+	}
+}
+
+func TestConfigSources(t *testing.T) {
+	// Arrange
+	siteSettings := settings.Settings{Precedence: []settings.Source{
+		{Name: mapping.ConfigKindOperator, Manifest: "operator.csv"},
+		{Name: mapping.ConfigKindAuto},
+	}}
+
+	// Execute test
+	got := ConfigSources(siteSettings)
+
+	// Assert results
+	want := []settings.Source{
+		{Name: mapping.ConfigKindOperator, Manifest: "operator.csv", Scheme: mapping.SchemeOperator},
+		{Name: mapping.ConfigKindAuto, Dir: AutoConfigDir, Scheme: mapping.SchemeAuto},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ConfigSources() = %v, want %v", got, want)
+	}
+}
+
+func TestSiteName(t *testing.T) {
+	tests := []struct {
+		siteDir string
+		want    string
+	}{
+		{"/archive/UCSB/MGS1", "MGS1"},
+		{"/archive/UCSB/MGS1/", "MGS1"},
+		{"snapshot.zip!/UCSB/MGS1", "MGS1"},
+	}
+
+	for _, tt := range tests {
+		if got := SiteName(tt.siteDir); got != tt.want {
+			t.Errorf("SiteName(%v) = %v, want %v", tt.siteDir, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,366 @@
+package watch
+
+import (
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/config_interval"
+	"git.axiom/axiom/range-series-config-mapper/internal/exclude"
+	"git.axiom/axiom/range-series-config-mapper/internal/mapping"
+	"git.axiom/axiom/range-series-config-mapper/internal/product"
+	"git.axiom/axiom/range-series-config-mapper/internal/read"
+	"git.axiom/axiom/range-series-config-mapper/internal/settings"
+	"git.axiom/axiom/range-series-config-mapper/internal/siteindex"
+)
+
+const (
+	// A product file was found for the first time
+	EventFile = "file"
+	// The config of a previously emitted file changed, e.g. because a new config now covers its timestamp
+	EventRemapped = "remapped"
+	// A new auto config ended the open-ended interval of the previously latest auto config
+	EventIntervalClosed = "interval_closed"
+)
+
+// Record is emitted for each change noticed while polling the site
+type Record struct {
+	Event   string     `json:"event"`
+	File    string     `json:"file,omitempty"`
+	Product string     `json:"product,omitempty"`
+	Time    *time.Time `json:"time,omitempty"`
+	// The timestamp as parsed from the file name, if the site's clock correction changed it
+	RawTime *time.Time `json:"raw_time,omitempty"`
+	// Set if the file was stamped within the boundary grace before the start of its config's interval
+	SnappedTo *time.Time `json:"snapped_to,omitempty"`
+	// Whether the timestamp lies exactly on the start or end of a config interval
+	OnBoundary bool `json:"on_boundary,omitempty"`
+	// Whether the file matched an auto config past its maximum validity
+	Stale bool `json:"stale,omitempty"`
+	// The operator window containing the file if it resolved to an auto config there
+	Unapproved string `json:"unapproved,omitempty"`
+	// The fallback that chose the config of a file outside every config interval
+	Fallback       string     `json:"fallback,omitempty"`
+	Config         string     `json:"config"`
+	Kind           string     `json:"kind,omitempty"`
+	PreviousConfig string     `json:"previous_config,omitempty"`
+	Start          *time.Time `json:"start,omitempty"`
+	End            *time.Time `json:"end,omitempty"`
+	ClosedBy       string     `json:"closed_by,omitempty"`
+}
+
+// After the first poll, only the date directories from this long before the newest file seen, or before now if that
+// file is stamped in the future, are scanned for new files. Files found stamped earlier are logged but not picked up.
+const rescanWindow = 24 * time.Hour
+
+var timeNow = func() time.Time {
+	return time.Now()
+}
+
+type fileState struct {
+	product string
+	rawTime time.Time
+	config  string
+}
+
+// Watcher resolves the configs of product files incrementally, remembering the files it has already seen
+type Watcher struct {
+	site         read.Site
+	settings     settings.Settings
+	products     []product.Product
+	exclusions   exclude.Rules
+	retention    time.Duration
+	emitExisting bool
+
+	polled   bool
+	files    map[string]fileState
+	skipped  map[string]bool
+	findings map[siteindex.Finding]bool
+	// The raw timestamp of the newest file seen
+	newest time.Time
+	// The config sources of the previous poll
+	precedence mapping.Precedence
+	// The open-ended interval of the latest config of each auto source
+	openIntervals map[string]config_interval.ConfigInterval
+}
+
+// New creates a watcher for the site, ignoring the excluded product files. Files are remembered, and remapped when
+// their config changes, until they are stamped more than the retention before the newest file, or forever if it is
+// zero. Unless emitExisting is set, the files present at the first poll are only recorded, and records are emitted for
+// files arriving afterwards.
+func New(site read.Site, siteSettings settings.Settings, products []product.Product, exclusions exclude.Rules, retention time.Duration, emitExisting bool) *Watcher {
+	return &Watcher{
+		site:         site,
+		settings:     siteSettings,
+		products:     products,
+		exclusions:   exclusions,
+		retention:    retention,
+		emitExisting: emitExisting,
+		files:        make(map[string]fileState),
+		skipped:      make(map[string]bool),
+		findings:     make(map[siteindex.Finding]bool),
+	}
+}
+
+// Poll rescans the site's configs and product files and returns the records for everything that changed since the
+// previous poll
+func (w *Watcher) Poll() ([]Record, error) {
+	idx, err := siteindex.Load(w.site, w.settings)
+	if err != nil {
+		return nil, err
+	}
+	w.logNewFindings(idx.Findings)
+
+	var res []Record
+
+	// 1. Notice when the latest auto config's open-ended interval was closed by a newer auto config
+	res = append(res, w.closedAutoIntervals(idx)...)
+	w.openIntervals = make(map[string]config_interval.ConfigInterval)
+	for _, source := range idx.Precedence {
+		if n := len(source.Intervals); source.Scheme == mapping.SchemeAuto && n > 0 {
+			w.openIntervals[source.Name] = source.Intervals[n-1]
+		}
+	}
+
+	// 2. Re-resolve the files seen so far if the config intervals changed, as new configs may cover them
+	if intervalsChanged(w.precedence, idx.Precedence) {
+		res = append(res, w.remapFiles(idx)...)
+	}
+	w.precedence = idx.Precedence
+
+	// 3. Resolve the files that arrived since the previous poll
+	scanRange := w.scanRange()
+	newFiles, err := w.findNewFiles(scanRange)
+	if err != nil {
+		return nil, err
+	}
+
+	emit := w.polled || w.emitExisting
+	for _, file := range newFiles {
+		prod := file.product
+		rawTime, ok := w.parseFile(file.path, prod)
+		if !ok {
+			continue
+		}
+		// Scanned directories may hold files older than the window, e.g. backfilled files. They are not mapped, as they
+		// may also be files that were forgotten.
+		if !scanRange.Contains(rawTime) {
+			log.Printf("Warning: %s file '%s' is stamped %v, before the scanned window starting %v, and is not mapped\n", prod.Name, file.path, rawTime.Format(time.RFC3339), scanRange.Start.Format(time.RFC3339))
+			w.skipped[file.path] = true
+			continue
+		}
+
+		resolution := idx.Resolve(rawTime)
+		w.files[file.path] = fileState{product: prod.Name, rawTime: rawTime, config: resolution.Config()}
+		if rawTime.After(w.newest) {
+			w.newest = rawTime
+		}
+
+		if emit {
+			res = append(res, newFileRecord(EventFile, file.path, prod.Name, resolution))
+		}
+	}
+	w.forgetOldFiles()
+
+	w.polled = true
+	return res, nil
+}
+
+// remapFiles re-resolves the files seen so far, and returns the records of those whose config changed
+func (w *Watcher) remapFiles(idx *siteindex.Index) []Record {
+	var res []Record
+
+	for _, file := range sortedKeys(w.files) {
+		state := w.files[file]
+		resolution := idx.Resolve(state.rawTime)
+		if resolution.Config() == state.config {
+			continue
+		}
+
+		record := newFileRecord(EventRemapped, file, state.product, resolution)
+		record.PreviousConfig = state.config
+		res = append(res, record)
+		state.config = resolution.Config()
+		w.files[file] = state
+	}
+
+	return res
+}
+
+// anchor returns the raw timestamp of the newest file seen, capped at now so that a file stamped in the future by a
+// wrong clock doesn't move the scan window past the files stamped correctly
+func (w *Watcher) anchor() time.Time {
+	if now := timeNow().UTC(); w.newest.After(now) {
+		return now
+	}
+	return w.newest
+}
+
+// scanRange limits the scan for new files to the time after the anchor, less the rescan window. The first poll scans
+// all files.
+func (w *Watcher) scanRange() read.TimeRange {
+	if w.newest.IsZero() {
+		return read.TimeRange{}
+	}
+	return read.TimeRange{Start: w.anchor()}.Widen(rescanWindow)
+}
+
+// forgetOldFiles drops the files stamped more than the retention before the anchor, which are no longer remapped.
+// Files within the rescan window are kept, so that they are not found again as new files.
+func (w *Watcher) forgetOldFiles() {
+	if w.retention <= 0 {
+		return
+	}
+
+	cutoff := w.anchor().Add(-max(w.retention, rescanWindow))
+	for file, state := range w.files {
+		if state.rawTime.Before(cutoff) {
+			delete(w.files, file)
+		}
+	}
+}
+
+// intervalsChanged reports whether the config sources, their configs or their intervals differ between polls. The ends
+// of open-ended intervals, which move with the time of loading, are ignored.
+func intervalsChanged(previous, current mapping.Precedence) bool {
+	return !slices.EqualFunc(previous, current, func(a, b mapping.Source) bool {
+		return a.Name == b.Name && slices.EqualFunc(a.Intervals, b.Intervals, sameInterval) && slices.EqualFunc(a.Excluded, b.Excluded, sameInterval)
+	})
+}
+
+func sameInterval(a, b config_interval.ConfigInterval) bool {
+	return a.Config == b.Config && a.Open == b.Open && a.Start.Equal(b.Start) && (a.Open || a.End.Equal(b.End))
+}
+
+// newFileRecord creates the record of a file whose config was resolved
+func newFileRecord(event string, file string, productName string, resolution mapping.Resolution) Record {
+	timeInterval, kind, _ := resolution.Match()
+	res := Record{
+		Event:      event,
+		File:       file,
+		Product:    productName,
+		Time:       &resolution.Time,
+		Config:     timeInterval.Config,
+		Kind:       kind,
+		OnBoundary: resolution.OnBoundary,
+		Stale:      resolution.Stale,
+		Unapproved: resolution.Unapproved,
+		Fallback:   resolution.Fallback,
+	}
+	if !resolution.Time.Equal(resolution.RawTime) {
+		res.RawTime = &resolution.RawTime
+	}
+	if resolution.Snapped {
+		res.SnappedTo = &resolution.LookupTime
+	}
+
+	return res
+}
+
+// closedAutoIntervals reports the open-ended intervals of auto sources in the previous poll that have since been ended
+// by a newer config of the same source
+func (w *Watcher) closedAutoIntervals(idx *siteindex.Index) []Record {
+	var res []Record
+
+	for _, source := range idx.Precedence {
+		open, ok := w.openIntervals[source.Name]
+		if !ok || source.Scheme != mapping.SchemeAuto {
+			continue
+		}
+
+		i := slices.IndexFunc(source.Intervals, func(c config_interval.ConfigInterval) bool {
+			return c.Config == open.Config
+		})
+		if i < 0 || i == len(source.Intervals)-1 {
+			continue
+		}
+
+		closed := source.Intervals[i]
+		res = append(res, Record{
+			Event:    EventIntervalClosed,
+			Config:   closed.Config,
+			Kind:     source.Name,
+			Start:    &closed.Start,
+			End:      &closed.End,
+			ClosedBy: source.Intervals[i+1].Config,
+		})
+	}
+
+	return res
+}
+
+type newFile struct {
+	path    string
+	product product.Product
+}
+
+// findNewFiles lists the product files within the range not seen in previous polls, sorted by path. Skipped files are
+// only remembered while they are within the range.
+func (w *Watcher) findNewFiles(timeRange read.TimeRange) ([]newFile, error) {
+	var res []newFile
+	skipped := make(map[string]bool)
+
+	for _, prod := range w.products {
+		files, err := read.FindProductFiles(w.site, prod, timeRange)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, file := range files {
+			if w.skipped[file] {
+				skipped[file] = true
+				continue
+			}
+			if _, ok := w.files[file]; ok {
+				continue
+			}
+			if rule, ok := w.exclusions.Excluded(w.site, file); ok {
+				log.Printf("Excluding %s file '%s': %s\n", prod.Name, file, rule)
+				skipped[file] = true
+				continue
+			}
+			res = append(res, newFile{path: file, product: prod})
+		}
+	}
+	w.skipped = skipped
+
+	slices.SortStableFunc(res, func(a, b newFile) int { return strings.Compare(a.path, b.path) })
+	return slices.CompactFunc(res, func(a, b newFile) bool { return a.path == b.path }), nil
+}
+
+// parseFile parses the timestamp of a file. Files that cannot be parsed are logged once and ignored.
+func (w *Watcher) parseFile(file string, prod product.Product) (time.Time, bool) {
+	fileTime, err := mapping.ParseProductTime(prod, file)
+	if err != nil {
+		log.Printf("Skipping %s file '%s': %v\n", prod.Name, file, err)
+		w.skipped[file] = true
+		return time.Time{}, false
+	}
+
+	return fileTime, true
+}
+
+func (w *Watcher) logNewFindings(findings []siteindex.Finding) {
+	for _, finding := range findings {
+		if w.findings[finding] {
+			continue
+		}
+		w.findings[finding] = true
+
+		if finding.Severity == siteindex.SeverityError {
+			log.Printf("Error: %v\n", finding.Message)
+		} else {
+			log.Printf("Warning: %v\n", finding.Message)
+		}
+	}
+}
+
+func sortedKeys(files map[string]fileState) []string {
+	var res []string
+	for file := range files {
+		res = append(res, file)
+	}
+	slices.Sort(res)
+	return res
+}
@@ -0,0 +1,209 @@
+package watch
+
+import (
+	"slices"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/config_interval"
+	"git.axiom/axiom/range-series-config-mapper/internal/exclude"
+	"git.axiom/axiom/range-series-config-mapper/internal/mapping"
+	"git.axiom/axiom/range-series-config-mapper/internal/product"
+	"git.axiom/axiom/range-series-config-mapper/internal/read"
+	"git.axiom/axiom/range-series-config-mapper/internal/settings"
+)
+
+func TestPoll(t *testing.T) {
+	// Arrange
+	siteFS := fstest.MapFS{
+		"Config_Auto/20230501T000000Z/Header.txt":                      {},
+		"Config_Operator/20230401T000000Z-20230402T000000Z/Header.txt": {},
+		"RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_070610.rs":         {},
+	}
+	watcher := New(read.NewSite(siteFS, "/archive/MGS1"), settings.Settings{}, []product.Product{product.RangeSeries}, exclude.Rules{}, 0, false)
+
+	// Define test cases, each adding files to the site before polling
+	tests := []struct {
+		name        string
+		added       []string
+		wantEvents  []string
+		wantConfigs []string
+	}{
+		{
+			name:  "Existing files are not emitted",
+			added: nil,
+		},
+		{
+			name:        "New file",
+			added:       []string{"RangeSeries/2023/05/21/Rng_mgs1_2023_05_21_000000.rs"},
+			wantEvents:  []string{EventFile},
+			wantConfigs: []string{"/archive/MGS1/Config_Auto/20230501T000000Z"},
+		},
+		{
+			name:        "Unparsable file",
+			added:       []string{"RangeSeries/2023/05/21/Rng_mgs1_notime.rs"},
+			wantEvents:  nil,
+			wantConfigs: nil,
+		},
+		{
+			name:  "New auto config closes the open interval",
+			added: []string{"Config_Auto/20230520T120000Z/Header.txt"},
+			wantEvents: []string{
+				EventIntervalClosed,
+				EventRemapped,
+			},
+			wantConfigs: []string{
+				"/archive/MGS1/Config_Auto/20230501T000000Z",
+				"/archive/MGS1/Config_Auto/20230520T120000Z",
+			},
+		},
+		{
+			name:        "File stamped before the scan window",
+			added:       []string{"RangeSeries/2023/05/01/Rng_mgs1_2023_05_01_000000.rs"},
+			wantEvents:  nil,
+			wantConfigs: nil,
+		},
+		{
+			name:        "Nothing changed",
+			added:       nil,
+			wantEvents:  nil,
+			wantConfigs: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, file := range tt.added {
+				siteFS[file] = &fstest.MapFile{}
+			}
+
+			// Execute test
+			records, err := watcher.Poll()
+			if err != nil {
+				t.Fatalf("Poll() error = %v", err)
+			}
+
+			// Assert results
+			if len(records) != len(tt.wantEvents) {
+				t.Fatalf("Poll() = %+v, want %d records", records, len(tt.wantEvents))
+			}
+			for i, record := range records {
+				if record.Event != tt.wantEvents[i] || record.Config != tt.wantConfigs[i] {
+					t.Errorf("Poll() record %d = %v %v, want %v %v", i, record.Event, record.Config, tt.wantEvents[i], tt.wantConfigs[i])
+				}
+			}
+		})
+	}
+
+	// The file stamped after the new auto config started was remapped
+	if got := watcher.files["/archive/MGS1/RangeSeries/2023/05/21/Rng_mgs1_2023_05_21_000000.rs"].config; got != "/archive/MGS1/Config_Auto/20230520T120000Z" {
+		t.Errorf("remapped config = %v", got)
+	}
+}
+
+func TestPollForgetsOldFiles(t *testing.T) {
+	// Arrange
+	siteFS := fstest.MapFS{
+		"Config_Auto/20230501T000000Z/Header.txt":              {},
+		"RangeSeries/2023/05/02/Rng_mgs1_2023_05_02_000000.rs": {},
+		"RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_000000.rs": {},
+	}
+	siteSettings := settings.Settings{Precedence: []settings.Source{{Name: "auto"}}}
+	watcher := New(read.NewSite(siteFS, "/archive/MGS1"), siteSettings, []product.Product{product.RangeSeries}, exclude.Rules{}, 7*24*time.Hour, false)
+
+	// Execute test
+	if _, err := watcher.Poll(); err != nil {
+		t.Fatalf("Poll() error = %v", err)
+	}
+
+	// Assert results
+	if _, ok := watcher.files["/archive/MGS1/RangeSeries/2023/05/02/Rng_mgs1_2023_05_02_000000.rs"]; ok {
+		t.Errorf("Poll() remembered the file stamped before the retention")
+	}
+	if _, ok := watcher.files["/archive/MGS1/RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_000000.rs"]; !ok {
+		t.Errorf("Poll() forgot the newest file")
+	}
+}
+
+func TestPollFutureFile(t *testing.T) {
+	// Arrange
+	siteFS := fstest.MapFS{
+		"Config_Auto/20230501T000000Z/Header.txt":              {},
+		"RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_000000.rs": {},
+	}
+	siteSettings := settings.Settings{Precedence: []settings.Source{{Name: "auto"}}}
+	watcher := New(read.NewSite(siteFS, "/archive/MGS1"), siteSettings, []product.Product{product.RangeSeries}, exclude.Rules{}, 0, false)
+
+	originalTimeNow := timeNow
+	timeNow = func() time.Time { return time.Date(2023, 5, 18, 0, 0, 0, 0, time.UTC) }
+	defer func() { timeNow = originalTimeNow }()
+
+	// Define test cases, each adding files to the site before polling
+	tests := []struct {
+		name      string
+		added     []string
+		wantFiles []string
+	}{
+		{"Existing files are not emitted", nil, nil},
+		{"File stamped in the future", []string{"RangeSeries/2024/01/01/Rng_mgs1_2024_01_01_000000.rs"}, []string{"/archive/MGS1/RangeSeries/2024/01/01/Rng_mgs1_2024_01_01_000000.rs"}},
+		{"File stamped correctly after the future file", []string{"RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_120000.rs"}, []string{"/archive/MGS1/RangeSeries/2023/05/17/Rng_mgs1_2023_05_17_120000.rs"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, file := range tt.added {
+				siteFS[file] = &fstest.MapFile{}
+			}
+
+			// Execute test
+			records, err := watcher.Poll()
+			if err != nil {
+				t.Fatalf("Poll() error = %v", err)
+			}
+
+			// Assert results
+			var got []string
+			for _, record := range records {
+				got = append(got, record.File)
+			}
+			if !slices.Equal(got, tt.wantFiles) {
+				t.Errorf("Poll() files = %v, want %v", got, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestIntervalsChanged(t *testing.T) {
+	// Arrange
+	start := time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC)
+	previous := mapping.Precedence{{Name: mapping.ConfigKindAuto, Scheme: mapping.SchemeAuto, Intervals: []config_interval.ConfigInterval{
+		{Start: start, End: start.AddDate(0, 0, 10), Config: "20230501T000000Z", Open: true},
+	}}}
+
+	// Define test cases
+	tests := []struct {
+		name      string
+		intervals []config_interval.ConfigInterval
+		want      bool
+	}{
+		{"Open interval loaded later", []config_interval.ConfigInterval{{Start: start, End: start.AddDate(0, 0, 11), Config: "20230501T000000Z", Open: true}}, false},
+		{"Interval closed", []config_interval.ConfigInterval{{Start: start, End: start.AddDate(0, 0, 10), Config: "20230501T000000Z"}}, true},
+		{"New config", []config_interval.ConfigInterval{
+			{Start: start, End: start.AddDate(0, 0, 5), Config: "20230501T000000Z"},
+			{Start: start.AddDate(0, 0, 5), End: start.AddDate(0, 0, 11), Config: "20230506T000000Z", Open: true},
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Execute test
+			got := intervalsChanged(previous, mapping.Precedence{{Name: mapping.ConfigKindAuto, Scheme: mapping.SchemeAuto, Intervals: tt.intervals}})
+
+			// Assert results
+			if got != tt.want {
+				t.Errorf("intervalsChanged() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -23,6 +23,19 @@ func SaveMapAsJson(myMap map[string]string, fileName string) {
 	}
 }
 
+// SaveAsJson writes any JSON-serializable data to the file
+func SaveAsJson(data any, fileName string) {
+	jsonData, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		log.Fatalf("Error marshalling data to JSON: %v", err)
+	}
+
+	err = os.WriteFile(fileName+jsonFileEnding, jsonData, 0644)
+	if err != nil {
+		log.Fatalf("Error writing JSON to file: %v", err)
+	}
+}
+
 func SaveMapAsCsv(myMap map[string]string, fileName string) {
 	// Create a new CSV file
 	file, err := os.Create(fileName + csvFileEnding)
@@ -43,3 +56,18 @@ func SaveMapAsCsv(myMap map[string]string, fileName string) {
 		}
 	}
 }
+
+// SaveRowsAsCsv writes each row as a line of the CSV file
+func SaveRowsAsCsv(rows [][]string, fileName string) {
+	file, err := os.Create(fileName + csvFileEnding)
+	if err != nil {
+		log.Fatalf("Error creating CSV file: %v", err)
+	}
+	defer file.Close()
+
+	// WriteAll flushes the writer itself
+	err = csv.NewWriter(file).WriteAll(rows)
+	if err != nil {
+		log.Fatalf("Error writing to CSV file: %v", err)
+	}
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/jobs"
+	"git.axiom/axiom/range-series-config-mapper/internal/product"
+	"git.axiom/axiom/range-series-config-mapper/internal/read"
+	"git.axiom/axiom/range-series-config-mapper/internal/siteindex"
+)
+
+const jobsCommand = "jobs"
+
+func runJobsCommand(args []string) {
+	flags := flag.NewFlagSet(jobsCommand, flag.ExitOnError)
+	siteDir := flags.String("site-dir", "", "Absolute path to HFR site directory.")
+	windowStr := flags.String("window", jobs.WindowDay, "Time span of each job of a config: 'config', 'day', 'month' or a duration such as '6h'.")
+	templateName := flags.String("template", "shell", "Template of the job manifests: 'shell', 'slurm', 'json' or the path to a Go text/template file.")
+	outDir := flags.String("out-dir", "", "Directory to write one manifest per job to. Defaults to writing all manifests to stdout.")
+	settingsFile := flags.String("settings", "", "Path to a JSON file with per-site settings.")
+	productNames := flags.String("products", product.RangeSeriesName, "Comma-separated list of the products to include in jobs.")
+	paths := addPathFlags(flags)
+	exclusions := addExclusionFlags(flags)
+	flags.Parse(args)
+
+	if *siteDir == "" {
+		log.Fatalln("Error: --site-dir must be specified.")
+	}
+
+	window, err := jobs.ParseWindow(*windowStr)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	tmpl, err := jobs.ParseTemplate(*templateName)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	siteSettings := loadSettings(*settingsFile)
+	site := openSite(*siteDir)
+
+	precedence := loadPrecedence(site, siteSettings)
+	products := selectProducts(strings.Split(*productNames, ","), siteSettings)
+	rewriter := paths.rewriter(site)
+	fileToConfig, _ := mapProductFiles(site, siteSettings, products, rewriteInputs(flags.Args(), rewriter), read.TimeRange{}, precedence, exclusions.rules(siteSettings))
+
+	siteJobs, err := jobs.Group(siteindex.SiteName(site.Root), rewriteMapping(fileToConfig, rewriter), products, window, loadPolicy(siteSettings).Clock)
+	if err != nil {
+		log.Fatalf("Error grouping files into jobs: %v", err)
+	}
+
+	if *outDir != "" {
+		if err := os.MkdirAll(*outDir, 0755); err != nil {
+			log.Fatalf("Error creating output directory: %v", err)
+		}
+	}
+
+	for _, job := range siteJobs {
+		var manifest bytes.Buffer
+		if err := tmpl.Render(&manifest, job); err != nil {
+			log.Fatalf("Error rendering job %s: %v", job.Name, err)
+		}
+
+		if *outDir == "" {
+			os.Stdout.Write(manifest.Bytes())
+			continue
+		}
+
+		// Scripts are made executable
+		perm := os.FileMode(0644)
+		if bytes.HasPrefix(manifest.Bytes(), []byte("#!")) {
+			perm = 0755
+		}
+		if err := os.WriteFile(filepath.Join(*outDir, job.Name+tmpl.Ext), manifest.Bytes(), perm); err != nil {
+			log.Fatalf("Error writing job %s: %v", job.Name, err)
+		}
+	}
+
+	log.Printf("Generated %d job(s)\n", len(siteJobs))
+}
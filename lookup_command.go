@@ -0,0 +1,205 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/mapping"
+	"git.axiom/axiom/range-series-config-mapper/internal/product"
+)
+
+const lookupCommand = "lookup"
+
+type lookupCandidate struct {
+	Config string    `json:"config"`
+	Kind   string    `json:"kind"`
+	Start  time.Time `json:"start"`
+	End    time.Time `json:"end"`
+}
+
+type lookupResult struct {
+	// The timestamp or file name that was looked up
+	Query string    `json:"query"`
+	Time  time.Time `json:"time"`
+	// The timestamp as parsed from the file name, if the site's clock correction changed it. Timestamps given with
+	// --time are not corrected.
+	RawTime *time.Time `json:"raw_time,omitempty"`
+	// Set for queries within the boundary grace before the start of their config's interval
+	SnappedTo  *time.Time `json:"snapped_to,omitempty"`
+	OnBoundary bool       `json:"on_boundary,omitempty"`
+	// Whether the query matched an auto config past its maximum validity
+	Stale bool `json:"stale,omitempty"`
+	// The operator window containing the query if it resolved to an auto config there
+	Unapproved string `json:"unapproved,omitempty"`
+	// The fallback that chose the config of a query outside every config interval
+	Fallback  string            `json:"fallback,omitempty"`
+	Config    *lookupCandidate  `json:"config"`
+	RunnerUps []lookupCandidate `json:"runner_ups"`
+}
+
+func newLookupCandidate(candidate mapping.Candidate) lookupCandidate {
+	return lookupCandidate{
+		Config: candidate.Interval.Config,
+		Kind:   candidate.Kind,
+		Start:  candidate.Interval.Start,
+		End:    candidate.Interval.End,
+	}
+}
+
+// newLookupResult reports the first of the candidates as the config, and the others as runner-ups. Without
+// candidates, the config chosen by a fallback is reported.
+func newLookupResult(query string, resolution mapping.Resolution) lookupResult {
+	res := lookupResult{
+		Query:      query,
+		Time:       resolution.Time,
+		OnBoundary: resolution.OnBoundary,
+		Stale:      resolution.Stale,
+		Unapproved: resolution.Unapproved,
+		RunnerUps:  []lookupCandidate{},
+	}
+	if !resolution.Time.Equal(resolution.RawTime) {
+		res.RawTime = &resolution.RawTime
+	}
+	if resolution.Snapped {
+		res.SnappedTo = &resolution.LookupTime
+	}
+
+	for i, candidate := range resolution.Candidates {
+		if i == 0 {
+			chosen := newLookupCandidate(candidate)
+			res.Config = &chosen
+			continue
+		}
+		res.RunnerUps = append(res.RunnerUps, newLookupCandidate(candidate))
+	}
+	if resolution.Fallback != "" {
+		fallback := newLookupCandidate(mapping.Candidate{Interval: resolution.FallbackInterval, Kind: resolution.FallbackKind})
+		res.Config, res.Fallback = &fallback, resolution.Fallback
+	}
+
+	return res
+}
+
+func formatCandidate(candidate lookupCandidate) string {
+	// The default config of the fallback has no interval
+	if candidate.Start.IsZero() {
+		return fmt.Sprintf("%v (%s)", candidate.Config, candidate.Kind)
+	}
+	return fmt.Sprintf("%v (%s, %v to %v)", candidate.Config, candidate.Kind, candidate.Start.Format(time.RFC3339), candidate.End.Format(time.RFC3339))
+}
+
+func writeLookupText(results []lookupResult) {
+	for _, result := range results {
+		if result.Query == result.Time.Format(time.RFC3339) {
+			fmt.Println(result.Query)
+		} else if result.RawTime != nil {
+			fmt.Printf("%v (%v, recorded as %v)\n", result.Query, result.Time.Format(time.RFC3339), result.RawTime.Format(time.RFC3339))
+		} else {
+			fmt.Printf("%v (%v)\n", result.Query, result.Time.Format(time.RFC3339))
+		}
+		if result.SnappedTo != nil {
+			fmt.Printf("  snapped:   to %v, within the boundary grace\n", result.SnappedTo.Format(time.RFC3339))
+		}
+		if result.OnBoundary {
+			fmt.Println("  boundary:  exactly on a config interval boundary")
+		}
+		if result.Stale {
+			fmt.Println("  stale:     auto config past its maximum validity")
+		}
+		if result.Unapproved != "" {
+			fmt.Printf("  approval:  auto config within operator window '%v'\n", result.Unapproved)
+		}
+
+		if result.Config == nil {
+			fmt.Println("  config:    none")
+			continue
+		}
+		if result.Fallback != "" {
+			fmt.Printf("  fallback:  %v\n", result.Fallback)
+		}
+		fmt.Printf("  config:    %v\n", formatCandidate(*result.Config))
+		for _, runnerUp := range result.RunnerUps {
+			fmt.Printf("  runner-up: %v\n", formatCandidate(runnerUp))
+		}
+	}
+}
+
+func runLookupCommand(args []string) {
+	var times repeatedFlag
+
+	flags := flag.NewFlagSet(lookupCommand, flag.ExitOnError)
+	siteDir := flags.String("site-dir", "", "Absolute path to HFR site directory.")
+	flags.Var(&times, "time", "Timestamp (RFC 3339 or 20060102T150405Z) to look up. Can be repeated.")
+	settingsFile := flags.String("settings", "", "Path to a JSON file with per-site settings.")
+	productNames := flags.String("products", product.RangeSeriesName, "Comma-separated list of the products whose file names can be looked up.")
+	outputFormat := flags.String("output-format", OutputFileTypeText, "The format of the results. Options are 'TEXT' or 'JSON'.")
+	paths := addPathFlags(flags)
+	flags.Parse(args)
+
+	fileNames := flags.Args()
+
+	if *siteDir == "" {
+		log.Fatalln("Error: --site-dir must be specified.")
+	}
+	if len(times) == 0 && len(fileNames) == 0 {
+		log.Fatalln("Error: Must specify at least one --time or file name.")
+	}
+	if !(*outputFormat == OutputFileTypeText || *outputFormat == OutputFileTypeJSON) {
+		log.Fatalf("Error: Invalid output-format of '%v'. Supported values are 'TEXT' and 'JSON'.\n", *outputFormat)
+	}
+
+	siteSettings := loadSettings(*settingsFile)
+	site := openSite(*siteDir)
+	precedence := loadPrecedence(site, siteSettings)
+	products := selectProducts(strings.Split(*productNames, ","), siteSettings)
+	policy := loadPolicy(siteSettings)
+
+	var results []lookupResult
+	for _, timeStr := range times {
+		timestamp, err := mapping.ParseTimestamp(timeStr)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		results = append(results, newLookupResult(timeStr, precedence.ResolveTime(timestamp, policy)))
+	}
+
+	for _, fileName := range fileNames {
+		prod, ok := product.ForFile(products, fileName)
+		if !ok {
+			log.Fatalf("Error: '%v' does not match any of the selected products", fileName)
+		}
+
+		rawTime, err := mapping.ParseProductTime(prod, fileName)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+
+		results = append(results, newLookupResult(fileName, precedence.Resolve(rawTime, policy)))
+	}
+
+	rewriter := paths.rewriter(site)
+	for _, result := range results {
+		if result.Config != nil {
+			result.Config.Config = rewriter.Output(result.Config.Config)
+		}
+		for i := range result.RunnerUps {
+			result.RunnerUps[i].Config = rewriter.Output(result.RunnerUps[i].Config)
+		}
+	}
+
+	if *outputFormat == OutputFileTypeJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(results); err != nil {
+			log.Fatalf("Error writing results: %v", err)
+		}
+		return
+	}
+
+	writeLookupText(results)
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/materialize"
+	"git.axiom/axiom/range-series-config-mapper/internal/pathmap"
+	"git.axiom/axiom/range-series-config-mapper/internal/product"
+	"git.axiom/axiom/range-series-config-mapper/internal/read"
+)
+
+const materializeCommand = "materialize"
+
+func runMaterializeCommand(args []string) {
+	flags := flag.NewFlagSet(materializeCommand, flag.ExitOnError)
+	siteDir := flags.String("site-dir", "", "Absolute path to HFR site directory.")
+	outDir := flags.String("out", "", "Directory in which a directory is created per config.")
+	mode := flags.String("mode", materialize.ModeSymlink, "How files are placed in the config directories. Options are 'symlink', 'hardlink' or 'copy'.")
+	dryRun := flags.Bool("dry-run", false, "Print the changes without making them.")
+	settingsFile := flags.String("settings", "", "Path to a JSON file with per-site settings.")
+	productNames := flags.String("products", product.RangeSeriesName, "Comma-separated list of the products to materialize.")
+	paths := addPathFlags(flags)
+	exclusions := addExclusionFlags(flags)
+	flags.Parse(args)
+
+	if *siteDir == "" || *outDir == "" {
+		log.Fatalln("Error: --site-dir and --out must be specified.")
+	}
+	if !slices.Contains(materialize.Modes, *mode) {
+		log.Fatalf("Error: Invalid mode of '%v'. Supported values are '%v'.\n", *mode, strings.Join(materialize.Modes, "', '"))
+	}
+	if len(paths.pathMaps) > 0 && *paths.relativePaths {
+		log.Fatalln("Error: --path-map and --relative-paths cannot be combined for symlink targets.")
+	}
+
+	out := filepath.Clean(*outDir)
+	siteSettings := loadSettings(*settingsFile)
+	site := openSite(*siteDir)
+
+	precedence := loadPrecedence(site, siteSettings)
+	products := selectProducts(strings.Split(*productNames, ","), siteSettings)
+	fileToConfig, _ := mapProductFiles(site, siteSettings, products, nil, read.TimeRange{}, precedence, exclusions.rules(siteSettings))
+
+	entries, err := materialize.Entries(site, fileToConfig, out, *mode)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	entries, err = materialize.RewriteLinks(entries, pathmap.New(paths.rules(), "").Output, *paths.relativePaths)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	actions, err := materialize.Plan(entries, out)
+	if err != nil {
+		log.Fatalf("Error comparing with %v: %v", out, err)
+	}
+
+	for _, action := range actions {
+		fmt.Println(action)
+	}
+
+	if *dryRun {
+		log.Printf("Dry run: %d change(s) not applied\n", len(actions))
+		return
+	}
+
+	if err := materialize.Apply(actions, out); err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	log.Printf("Applied %d change(s), %d file(s) up to date\n", len(actions), len(entries)-countCreated(actions))
+}
+
+// countCreated counts the actions that create or replace an entry
+func countCreated(actions []materialize.Action) int {
+	n := 0
+	for _, action := range actions {
+		if action.Op != materialize.OpRemove {
+			n++
+		}
+	}
+	return n
+}
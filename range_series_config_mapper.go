@@ -2,37 +2,183 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"io/fs"
 	"log"
+	"maps"
+	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"time"
 
-	"git.axiom/axiom/range-series-config-mapper/internal/logger"
+	"git.axiom/axiom/range-series-config-mapper/internal/exclude"
 	"git.axiom/axiom/range-series-config-mapper/internal/mapping"
+	"git.axiom/axiom/range-series-config-mapper/internal/pathmap"
+	"git.axiom/axiom/range-series-config-mapper/internal/product"
 	"git.axiom/axiom/range-series-config-mapper/internal/read"
+	"git.axiom/axiom/range-series-config-mapper/internal/settings"
+	"git.axiom/axiom/range-series-config-mapper/internal/sitecode"
+	"git.axiom/axiom/range-series-config-mapper/internal/siteindex"
 	"git.axiom/axiom/range-series-config-mapper/internal/write"
 )
 
-const (
-	autoConfigDir     = "Config_Auto"
-	operatorConfigDir = "Config_Operator"
-	rangeSeriesDir    = "RangeSeries"
-)
-
-const (
-	configFileNamePattern      = `\d{4}\d{2}\d{2}T\d{2}\d{2}\d{2}Z(-(\d{4}\d{2}\d{2}T\d{2}\d{2}\d{2}Z|present))?$`
-	rangeSeriesFilePathPattern = `\d{4}\/\d{2}\/\d{2}/.*.rs$`
-)
-
 const (
 	OutputFileTypeJSON = "JSON"
 	OutputFileTypeCSV  = "CSV"
+	OutputFileTypeText = "TEXT"
 )
 
-func parseArgs() ([]string, bool, string, string, string) {
+// Appended to the output file name for the explanations written with --explain
+const explainFileSuffix = "_explain"
+
+// Subcommands are selected by the first CLI argument. Without one, the RangeSeries:Config mapping is computed.
+var subcommands = map[string]func(args []string){
+	diffCommand:        runDiffCommand,
+	serveCommand:       runServeCommand,
+	watchCommand:       runWatchCommand,
+	materializeCommand: runMaterializeCommand,
+	jobsCommand:        runJobsCommand,
+	lookupCommand:      runLookupCommand,
+	resolveCommand:     runResolveCommand,
+}
+
+// repeatedFlag collects the values of a flag that can be given more than once
+type repeatedFlag []string
+
+func (f *repeatedFlag) String() string {
+	return strings.Join(*f, " ")
+}
+
+func (f *repeatedFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// pathFlags control how paths are written to output, and read from input
+type pathFlags struct {
+	pathMaps      repeatedFlag
+	relativePaths *bool
+}
+
+func addPathFlags(flags *flag.FlagSet) *pathFlags {
+	res := &pathFlags{}
+	flags.Var(&res.pathMaps, "path-map", "Rewrite the path prefix /old to /new in output, and /new to /old in input, "+
+		"given as '/old=/new'. Can be repeated; the first matching rule applies.")
+	res.relativePaths = flags.Bool("relative-paths", false, "Write paths within the site directory relative to it.")
+	return res
+}
+
+// rules parses the path maps
+func (f *pathFlags) rules() []pathmap.Rule {
+	var res []pathmap.Rule
+	for _, pathMap := range f.pathMaps {
+		rule, err := pathmap.ParseRule(pathMap)
+		if err != nil {
+			log.Fatalf("Error: %v", err)
+		}
+		res = append(res, rule)
+	}
+
+	return res
+}
+
+// rewriter returns the rewriter for the paths of the site
+func (f *pathFlags) rewriter(site read.Site) pathmap.Rewriter {
+	var relativeTo string
+	if *f.relativePaths {
+		relativeTo = site.Root
+	}
+
+	return pathmap.New(f.rules(), relativeTo)
+}
+
+// exclusionFlags select the product files taking part in a run, in addition to the site's include and exclude rules
+type exclusionFlags struct {
+	includes repeatedFlag
+	excludes repeatedFlag
+}
+
+func addExclusionFlags(flags *flag.FlagSet) *exclusionFlags {
+	res := &exclusionFlags{}
+	flags.Var(&res.includes, "include", "Only map the product files matching this glob pattern, e.g. 'RangeSeries/2023/*'. "+
+		"Patterns without a slash match file and directory names. Can be repeated.")
+	flags.Var(&res.excludes, "exclude", "Leave out the product files matching this glob pattern, e.g. '._*' or '*.partial'. "+
+		"Patterns without a slash match file and directory names. Can be repeated.")
+	return res
+}
+
+// rules combines the flags with the site's include and exclude rules and blocklist
+func (f *exclusionFlags) rules(siteSettings settings.Settings) exclude.Rules {
+	rules, err := siteSettings.Exclusions(f.includes, f.excludes)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	return rules
+}
+
+// rewriteMapping rewrites the file and config paths of a mapping for output
+func rewriteMapping(fileToConfig map[string]string, rewriter pathmap.Rewriter) map[string]string {
+	if rewriter.IsZero() {
+		return fileToConfig
+	}
+
+	res := make(map[string]string, len(fileToConfig))
+	for file, config := range fileToConfig {
+		if config != "" {
+			config = rewriter.Output(config)
+		}
+		res[rewriter.Output(file)] = config
+	}
+
+	return res
+}
+
+// rewriteInputs rewrites paths read from input into local paths
+func rewriteInputs(paths []string, rewriter pathmap.Rewriter) []string {
+	res := make([]string, len(paths))
+	for i, path := range paths {
+		res[i] = rewriter.Input(path)
+	}
+
+	return res
+}
+
+type mapperArgs struct {
+	targetRangeSeriesFiles []string
+	allRangeSeries         bool
+	siteDir                string
+	outputFileType         string
+	outputFileName         string
+	settingsFile           string
+	productNames           []string
+	explain                bool
+	timeRange              read.TimeRange
+	filesFrom              string
+	nullDelimited          bool
+	paths                  *pathFlags
+	exclusions             *exclusionFlags
+}
+
+func parseArgs() mapperArgs {
 	siteDir := flag.String("site-dir", "", "Absolute path to HFR site directory.")
 	allRangeSeries := flag.Bool("all", false, "Boolean flag indicating whether to produce a mapping for all "+
-		"RangeSeries files for the site. If set, `siteDir/RangeSeries` will be scanned for RangeSeries files.")
+		"RangeSeries files for the site. If set, `siteDir/RangeSeries` will be scanned for RangeSeries files "+
+		"(or the directory of each product given with --products).")
 	outputFileType := flag.String("output-file-type", "JSON", "The format of the output file. Options are 'JSON' or 'CSV'.")
 	outputFileName := flag.String("output-file-name", "rangeseries_to_config", "The name of the output file. Should not include the file ending.")
+	settingsFile := flag.String("settings", "", "Path to a JSON file with per-site settings.")
+	productNames := flag.String("products", product.RangeSeriesName, "Comma-separated list of the products to map, "+
+		"e.g. 'RangeSeries,CSQ,RDLm'. Built-in products are RangeSeries, CSS, CSQ, RDLi, RDLm and WVLM.")
+	explain := flag.Bool("explain", false, "Also write, per file, the parsed timestamp, the configs whose intervals contain it "+
+		"and why one was chosen, to `<output-file-name>_explain.json`.")
+	start := flag.String("start", "", "Only map files stamped at or after this time (RFC 3339 or 20060102T150405Z).")
+	end := flag.String("end", "", "Only map files stamped before this time (RFC 3339 or 20060102T150405Z).")
+	filesFrom := flag.String("files-from", "", "Read the RangeSeries files to map from this file, or stdin if '-'. One path or "+
+		"glob pattern per line, relative paths being resolved against the site's RangeSeries directory.")
+	nullDelimited := flag.Bool("null", false, "Paths read with --files-from are separated by NUL bytes, e.g. from `find -print0`.")
+	paths := addPathFlags(flag.CommandLine)
+	exclusions := addExclusionFlags(flag.CommandLine)
 
 	flag.Parse()
 
@@ -42,89 +188,522 @@ func parseArgs() ([]string, bool, string, string, string) {
 	log.Println("Output file type:", *outputFileType)
 	log.Println("Output file name:", *outputFileName)
 	log.Println("Targetting all RangeSeries files:", *allRangeSeries)
+	log.Println("Products:", *productNames)
+
+	timeRange, err := parseTimeRange(*start, *end)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
 
-	return targetRangeSeriesFiles, *allRangeSeries, *siteDir, *outputFileType, *outputFileName
+	return mapperArgs{
+		targetRangeSeriesFiles: targetRangeSeriesFiles,
+		allRangeSeries:         *allRangeSeries,
+		siteDir:                *siteDir,
+		outputFileType:         *outputFileType,
+		outputFileName:         *outputFileName,
+		settingsFile:           *settingsFile,
+		productNames:           strings.Split(*productNames, ","),
+		explain:                *explain,
+		timeRange:              timeRange,
+		filesFrom:              *filesFrom,
+		nullDelimited:          *nullDelimited,
+		paths:                  paths,
+		exclusions:             exclusions,
+	}
 }
 
-func validateArgs(targetRangeseriesFiles []string, allRangeSeries bool, siteDir, outputFileType, outputFileName string) {
+// parseTimeRange parses the optional bounds of a time range
+func parseTimeRange(start string, end string) (read.TimeRange, error) {
+	var res read.TimeRange
+	var err error
+
+	if start != "" {
+		if res.Start, err = mapping.ParseTimestamp(start); err != nil {
+			return res, err
+		}
+	}
+	if end != "" {
+		if res.End, err = mapping.ParseTimestamp(end); err != nil {
+			return res, err
+		}
+	}
+
+	if !res.Start.IsZero() && !res.End.IsZero() && !res.Start.Before(res.End) {
+		return res, fmt.Errorf("start %v must be before end %v", start, end)
+	}
+
+	return res, nil
+}
+
+func validateArgs(args mapperArgs) {
 	// siteDir must be specified
-	if siteDir == "" {
+	if args.siteDir == "" {
 		log.Fatalln("Error: --site-dir must be specified.")
 	}
 
-	// allRangeSeries and non-empty targetRangeseriesFiles are mutually-exclusive
-	if allRangeSeries && len(targetRangeseriesFiles) > 0 {
+	// allRangeSeries and individual RangeSeries files (as arguments or with filesFrom) are mutually-exclusive
+	hasTargetFiles := len(args.targetRangeSeriesFiles) > 0 || args.filesFrom != ""
+	if args.allRangeSeries && hasTargetFiles {
 		log.Fatalln("Error: Cannot specify individual RangeSeries files when the -all flag is active.")
-	} else if !allRangeSeries && len(targetRangeseriesFiles) == 0 {
+	} else if !args.allRangeSeries && !hasTargetFiles {
 		log.Fatalln("Error: Must specify individual RangeSeries files when the -all flag is inactive.")
 	}
 
 	// outputFileType can only be `JSON` or `CSV`
-	if !(outputFileType == OutputFileTypeJSON || outputFileType == OutputFileTypeCSV) {
-		log.Fatalf("Error: Invalid output-file-type of '%v'. Supported values are 'JSON' and 'CSV'.\n", outputFileType)
+	if !(args.outputFileType == OutputFileTypeJSON || args.outputFileType == OutputFileTypeCSV) {
+		log.Fatalf("Error: Invalid output-file-type of '%v'. Supported values are 'JSON' and 'CSV'.\n", args.outputFileType)
 	}
 }
 
-func readConfigFiles(siteDir string, configType string) []string {
-	log.Printf("Checking following path for configs: %v\n", filepath.Join(siteDir, configType))
+func loadSettings(settingsFile string) settings.Settings {
+	siteSettings, err := settings.Load(settingsFile)
+	if err != nil {
+		log.Fatalf("Error reading settings file %v: %v", settingsFile, err)
+	}
+
+	return siteSettings
+}
 
-	configPaths, err := read.FindFilesMatchingPattern(filepath.Join(siteDir, configType), configFileNamePattern, true)
+func openSite(siteDir string) read.Site {
+	site, err := read.OpenSite(siteDir)
 	if err != nil {
-		log.Fatalf("Error reading %s files: %v", configType, err)
+		log.Fatalf("Error opening site %v: %v", siteDir, err)
 	}
 
-	return configPaths
+	return site
 }
 
-func readRangeSeriesFiles(siteDir string) []string {
-	log.Printf("Checking following path for RangeSeries files: %v\n", filepath.Join(siteDir, rangeSeriesDir))
+func selectProducts(productNames []string, siteSettings settings.Settings) []product.Product {
+	products, err := product.Select(productNames, siteSettings.Products)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	return products
+}
+
+// listArchiveMembers returns the display paths of the archive's members belonging to any of the products
+func listArchiveMembers(fsys fs.FS, archivePath string, displayPath string, products []product.Product) []string {
+	var res []string
+
+	for _, prod := range products {
+		members, err := read.ListArchiveMembers(fsys, archivePath, prod.FileNamePattern)
+		if err != nil {
+			log.Fatalf("Error reading %s archive %v: %v", prod.Name, displayPath, err)
+		}
 
-	paths, err := read.FindFilesMatchingPattern(filepath.Join(siteDir, rangeSeriesDir), rangeSeriesFilePathPattern, false)
+		for _, member := range members {
+			res = append(res, read.ArchiveMemberPath(displayPath, member))
+		}
+	}
+
+	return res
+}
+
+// expandArchives replaces each archive in paths by its members belonging to any of the products
+func expandArchives(paths []string, products []product.Product) []string {
+	var res []string
+
+	for _, path := range paths {
+		if !read.IsArchive(path) {
+			res = append(res, path)
+			continue
+		}
+
+		res = append(res, listArchiveMembers(os.DirFS(filepath.Dir(path)), filepath.Base(path), path, products)...)
+	}
+
+	return res
+}
+
+// groupFilesByProduct assigns each file to the product whose naming it follows. When a single product is mapped,
+// all files are assigned to it.
+func groupFilesByProduct(paths []string, products []product.Product) map[string][]string {
+	res := make(map[string][]string)
+
+	for _, path := range paths {
+		if len(products) == 1 {
+			res[products[0].Name] = append(res[products[0].Name], path)
+			continue
+		}
+
+		prod, ok := product.ForFile(products, path)
+		if !ok {
+			log.Printf("Skipping file '%s': does not match any of the selected products\n", path)
+			continue
+		}
+		res[prod.Name] = append(res[prod.Name], path)
+	}
+
+	return res
+}
+
+func readProductFiles(site read.Site, prod product.Product, timeRange read.TimeRange) []string {
+	log.Printf("Checking following path for %s files: %v\n", prod.Name, site.Path(prod.Dir))
+
+	res, err := read.FindProductFiles(site, prod, timeRange)
 	if err != nil {
-		log.Fatalf("Error reading RangeSeries files: %v", err)
+		log.Fatalf("Error reading %s files: %v", prod.Name, err)
 	}
 
-	return paths
+	return res
 }
 
-func writeResult(mapping map[string]string, format string, fileName string) {
+// mappingRecord is the entry of a file in the mapping output, if the site's settings change how configs are found
+type mappingRecord struct {
+	File   string `json:"file"`
+	Config string `json:"config"`
+	// The timestamp as corrected for the site's clock, and as parsed from the file name if the correction changed it
+	Time    time.Time  `json:"time"`
+	RawTime *time.Time `json:"raw_time,omitempty"`
+	// Set if the file was stamped within the boundary grace before the start of its config's interval
+	SnappedTo *time.Time `json:"snapped_to,omitempty"`
+	// The fallback that chose the config of a file outside every config interval
+	Fallback string `json:"fallback,omitempty"`
+}
+
+// csvRow lists the fields of the record in the order of mappingCsvHeader
+func (r mappingRecord) csvRow() []string {
+	return []string{r.File, r.Config, r.Time.Format(time.RFC3339), formatOptionalTime(r.RawTime), formatOptionalTime(r.SnappedTo), r.Fallback}
+}
+
+var mappingCsvHeader = []string{"file", "config", "time", "raw_time", "snapped_to", "fallback"}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.RFC3339)
+}
+
+// recordsMapping reports whether the mapping is written as records of how each config was found, rather than only
+// pairing each file with its config
+func recordsMapping(policy mapping.Policy) bool {
+	return !policy.Clock.IsZero() || policy.BoundaryGrace > 0 || policy.Fallback != ""
+}
+
+// mappingRecords lists the mapped files by product, each with the times its config was looked up by and the fallback
+// that chose it, if any
+func mappingRecords(products []product.Product, filesByProduct map[string][]string, fileToConfig map[string]string, precedence mapping.Precedence, policy mapping.Policy, rewriter pathmap.Rewriter) []mappingRecord {
+	records := []mappingRecord{}
+	for _, prod := range products {
+		for _, path := range filesByProduct[prod.Name] {
+			// Files left out of the mapping, e.g. with unparsable timestamps, are left out of the records as well
+			config, ok := fileToConfig[path]
+			if !ok {
+				continue
+			}
+			rawTime, err := mapping.ParseProductTime(prod, path)
+			if err != nil {
+				continue
+			}
+			resolution := precedence.Resolve(rawTime, policy)
+
+			record := mappingRecord{File: rewriter.Output(path), Config: config, Time: resolution.Time, Fallback: resolution.Fallback}
+			if config != "" {
+				record.Config = rewriter.Output(config)
+			}
+			if !resolution.Time.Equal(resolution.RawTime) {
+				record.RawTime = &resolution.RawTime
+			}
+			if resolution.Snapped {
+				record.SnappedTo = &resolution.LookupTime
+			}
+			records = append(records, record)
+		}
+	}
+
+	return records
+}
+
+// writeResult saves the file to config mapping. If the site's settings change how configs are found, each file is
+// written with the times its config was looked up by.
+func writeResult(products []product.Product, filesByProduct map[string][]string, fileToConfig map[string]string, precedence mapping.Precedence, policy mapping.Policy, rewriter pathmap.Rewriter, format string, fileName string) {
 	log.Println("Writing mapping to disk...")
 
+	if !recordsMapping(policy) {
+		if format == OutputFileTypeJSON {
+			write.SaveMapAsJson(rewriteMapping(fileToConfig, rewriter), fileName)
+		} else if format == OutputFileTypeCSV {
+			write.SaveMapAsCsv(rewriteMapping(fileToConfig, rewriter), fileName)
+		}
+		return
+	}
+
+	records := mappingRecords(products, filesByProduct, fileToConfig, precedence, policy, rewriter)
 	if format == OutputFileTypeJSON {
-		write.SaveMapAsJson(mapping, fileName)
+		write.SaveAsJson(records, fileName)
 	} else if format == OutputFileTypeCSV {
-		write.SaveMapAsCsv(mapping, fileName)
+		rows := [][]string{mappingCsvHeader}
+		for _, record := range records {
+			rows = append(rows, record.csvRow())
+		}
+		write.SaveRowsAsCsv(rows, fileName)
+	}
+}
+
+// loadSiteIndex loads the site's config intervals, exiting if the configs are invalid
+func loadSiteIndex(site read.Site, siteSettings settings.Settings) *siteindex.Index {
+	idx, err := siteindex.Load(site, siteSettings)
+	if err != nil {
+		log.Fatalf("Error loading configs: %v", err)
+	}
+
+	for _, finding := range idx.Findings {
+		if finding.Severity == siteindex.SeverityError {
+			log.Fatalf("Error: %v", finding.Message)
+		}
+		log.Printf("Warning: %v\n", finding.Message)
+	}
+
+	return idx
+}
+
+// loadPolicy returns the site's rules for resolving file timestamps to configs
+func loadPolicy(siteSettings settings.Settings) mapping.Policy {
+	policy, err := siteSettings.Policy()
+	if err != nil {
+		log.Fatalf("Error: %v", err)
 	}
+	return policy
+}
+
+// loadPrecedence loads the config intervals of the site's config sources, in order of precedence
+func loadPrecedence(site read.Site, siteSettings settings.Settings) mapping.Precedence {
+	return loadSiteIndex(site, siteSettings).Precedence
+}
+
+// applySiteCodePolicy checks that the mapped files of the product belong to the site and handles mismatches according
+// to the site's policy. Skipped files are removed from the mapping and from the returned files.
+func applySiteCodePolicy(prod product.Product, productPaths []string, productToConfig map[string]string, site read.Site, siteSettings settings.Settings) []string {
+	if siteSettings.SiteCodePolicy == "" {
+		return productPaths
+	}
+
+	siteCode := siteindex.SiteName(site.Root)
+	log.Printf("Checking %v site codes against '%v'...\n", prod.Name, siteCode)
+
+	mismatches, err := sitecode.Check(site, productToConfig, siteCode, siteSettings.CheckConfigSiteCode)
+	if err != nil {
+		log.Fatalf("Error checking site codes: %v", err)
+	}
+
+	skipped := make(map[string]bool)
+	for _, mismatch := range mismatches {
+		switch siteSettings.SiteCodePolicy {
+		case sitecode.PolicyWarn:
+			log.Printf("Warning: %v\n", mismatch)
+		case sitecode.PolicySkip:
+			log.Printf("Skipping %v file: %v\n", prod.Name, mismatch)
+			delete(productToConfig, mismatch.File)
+			skipped[mismatch.File] = true
+		case sitecode.PolicyError:
+			log.Printf("Error: %v\n", mismatch)
+		}
+	}
+
+	if siteSettings.SiteCodePolicy == sitecode.PolicyError && len(mismatches) > 0 {
+		log.Fatalf("Error: %d %v file(s) do not match the site code '%v'", len(mismatches), prod.Name, siteCode)
+	}
+
+	return slices.DeleteFunc(productPaths, func(path string) bool { return skipped[path] })
+}
+
+// readFilesFrom reads the list of files to map from a file or stdin, expanding relative paths and glob patterns in the
+// products' directories
+func readFilesFrom(filesFrom string, nullDelimited bool, site read.Site, products []product.Product, rewriter pathmap.Rewriter) []string {
+	input := os.Stdin
+	if filesFrom != "-" {
+		file, err := os.Open(filesFrom)
+		if err != nil {
+			log.Fatalf("Error opening file list: %v", err)
+		}
+		defer file.Close()
+		input = file
+	}
+
+	entries, err := read.ReadFileList(input, nullDelimited)
+	if err != nil {
+		log.Fatalf("Error reading file list %v: %v", filesFrom, err)
+	}
+
+	var dirs []string
+	for _, prod := range products {
+		if !slices.Contains(dirs, prod.Dir) {
+			dirs = append(dirs, prod.Dir)
+		}
+	}
+
+	res, err := read.ExpandFileList(site, dirs, rewriteInputs(entries, rewriter))
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	log.Printf("Read %d file(s) from %v\n", len(res), filesFrom)
+	return res
+}
+
+// filterByTime keeps the files whose corrected timestamp lies within the time range. Files whose timestamp cannot be
+// parsed are kept, so that the mapping reports them.
+func filterByTime(prod product.Product, paths []string, timeRange read.TimeRange, correction mapping.ClockCorrection) []string {
+	if timeRange.IsZero() {
+		return paths
+	}
+
+	var res []string
+	for _, path := range paths {
+		productTime, err := mapping.ParseProductTime(prod, path)
+		if err == nil && !timeRange.Contains(correction.Apply(productTime)) {
+			continue
+		}
+		res = append(res, path)
+	}
+
+	return res
+}
+
+// mapProductFiles maps the target files to configs, or all of the products' files in the site if none are given,
+// limited to the time range and leaving out the excluded files. It also returns the files considered for each product.
+func mapProductFiles(site read.Site, siteSettings settings.Settings, products []product.Product, targetFiles []string, timeRange read.TimeRange, precedence mapping.Precedence, exclusions exclude.Rules) (map[string]string, map[string][]string) {
+	var targetFilesByProduct map[string][]string
+	if len(targetFiles) > 0 {
+		targetFilesByProduct = groupFilesByProduct(expandArchives(targetFiles, products), products)
+	}
+
+	policy := loadPolicy(siteSettings)
+
+	res := make(map[string]string)
+	filesByProduct := make(map[string][]string)
+	var excluded []exclude.Exclusion
+	for _, prod := range products {
+		var productFilePaths []string
+		if targetFilesByProduct == nil {
+			// Date directories hold files by their recorded timestamps, which may lie outside the range once corrected
+			productFilePaths = readProductFiles(site, prod, timeRange.Widen(policy.Clock.MaxShift()))
+		} else {
+			productFilePaths = targetFilesByProduct[prod.Name]
+		}
+		productFilePaths = filterByTime(prod, productFilePaths, timeRange, policy.Clock)
+		productFilePaths, productExcluded := exclusions.Filter(site, productFilePaths)
+		excluded = append(excluded, productExcluded...)
+
+		productToConfig := precedence.MapProductFiles(prod, productFilePaths, policy)
+		filesByProduct[prod.Name] = applySiteCodePolicy(prod, productFilePaths, productToConfig, site, siteSettings)
+		maps.Copy(res, productToConfig)
+	}
+	warnStaleAutoConfigs(products, filesByProduct, precedence, policy)
+	logRunSummary(res, excluded)
+
+	return res, filesByProduct
+}
+
+// logRunSummary reports how many files were mapped, and lists the excluded files with the rule that excluded each
+func logRunSummary(fileToConfig map[string]string, excluded []exclude.Exclusion) {
+	unmapped := 0
+	for _, config := range fileToConfig {
+		if config == "" {
+			unmapped++
+		}
+	}
+
+	log.Printf("Summary: %d file(s) mapped, %d without a config, %d excluded\n", len(fileToConfig)-unmapped, unmapped, len(excluded))
+	for _, exclusion := range excluded {
+		log.Printf("Excluded '%s': %s\n", exclusion.File, exclusion.Rule)
+	}
+}
+
+// warnStaleAutoConfigs warns if the newest auto config of any source started longer before the newest of the files than
+// the site allows, e.g. because the site stopped writing configs
+func warnStaleAutoConfigs(products []product.Product, filesByProduct map[string][]string, precedence mapping.Precedence, policy mapping.Policy) {
+	if policy.StaleAutoWarning <= 0 {
+		return
+	}
+
+	var newest time.Time
+	for _, prod := range products {
+		for _, path := range filesByProduct[prod.Name] {
+			if productTime, err := mapping.ParseProductTime(prod, path); err == nil {
+				if productTime = policy.Clock.Apply(productTime); productTime.After(newest) {
+					newest = productTime
+				}
+			}
+		}
+	}
+	if newest.IsZero() {
+		return
+	}
+
+	for _, stale := range precedence.StaleAutoConfigs(newest, policy.StaleAutoWarning) {
+		log.Printf("Warning: the newest %s config %v started at %v, more than %v before the newest file at %v\n", stale.Kind,
+			stale.Interval.Config, stale.Interval.Start.Format(time.RFC3339), policy.StaleAutoWarning, newest.Format(time.RFC3339))
+	}
+}
+
+// writeExplanations records how the config of each file was determined
+func writeExplanations(products []product.Product, filesByProduct map[string][]string, precedence mapping.Precedence, policy mapping.Policy, rewriter pathmap.Rewriter, fileName string) {
+	log.Println("Writing explanations to disk...")
+
+	explanations := []mapping.Explanation{}
+	for _, prod := range products {
+		for _, path := range filesByProduct[prod.Name] {
+			explanation := precedence.ExplainProductFile(prod, path, policy)
+
+			explanation.File = rewriter.Output(explanation.File)
+			if explanation.Config != "" {
+				explanation.Config = rewriter.Output(explanation.Config)
+			}
+			for i := range explanation.Candidates {
+				explanation.Candidates[i].Config = rewriter.Output(explanation.Candidates[i].Config)
+			}
+			explanations = append(explanations, explanation)
+		}
+	}
+
+	write.SaveAsJson(explanations, fileName+explainFileSuffix)
 }
 
 func main() {
+	// 0. Dispatch to a subcommand, if one was given
+	if len(os.Args) > 1 {
+		if runCommand, ok := subcommands[os.Args[1]]; ok {
+			runCommand(os.Args[2:])
+			return
+		}
+	}
+
 	// 1. Parse CLI args
-	targetRangeseriesFiles, allRangeSeries, siteDir, outputFileType, outputFileName := parseArgs()
-	validateArgs(targetRangeseriesFiles, allRangeSeries, siteDir, outputFileType, outputFileName)
+	args := parseArgs()
+	validateArgs(args)
+	siteSettings := loadSettings(args.settingsFile)
+	site := openSite(args.siteDir)
 
 	// 2. Build mapping of time intervals to configs
-	// 2.a. Retrieve configs
-	autoConfigs := readConfigFiles(siteDir, autoConfigDir)
-	operatorConfigs := readConfigFiles(siteDir, operatorConfigDir)
+	precedence := loadPrecedence(site, siteSettings)
 
-	// 2.b. Build mapping of time intervals to configs
-	autoConfigIntervals := mapping.BuildAutoConfigIntervals(autoConfigs)
-	operatorConfigIntervals := mapping.BuildOperatorConfigIntervals(operatorConfigs)
+	// 3. Build mapping of product files (e.g. RangeSeries) to Config directories
+	products := selectProducts(args.productNames, siteSettings)
 
-	// 2.c. Validate configs
-	mapping.ValidateOperatorConfigs(operatorConfigIntervals, &logger.StdLogger{})
+	rewriter := args.paths.rewriter(site)
 
-	// 3. Build mapping of RangeSeries files to Config directories
-	var rangeSeriesFilePaths []string
-	if allRangeSeries {
-		rangeSeriesFilePaths = readRangeSeriesFiles(siteDir)
-	} else {
-		rangeSeriesFilePaths = targetRangeseriesFiles
+	var targetFiles []string
+	if !args.allRangeSeries {
+		targetFiles = rewriteInputs(args.targetRangeSeriesFiles, rewriter)
 	}
-
-	rangeSeriesToConfig := mapping.CreateRangeSeriesToConfigMap(rangeSeriesFilePaths, autoConfigIntervals, operatorConfigIntervals)
+	if args.filesFrom != "" {
+		targetFiles = append(targetFiles, readFilesFrom(args.filesFrom, args.nullDelimited, site, products, rewriter)...)
+	}
+	if !args.allRangeSeries && len(targetFiles) == 0 {
+		log.Fatalln("Error: None of the listed RangeSeries files were found.")
+	}
+	rangeSeriesToConfig, filesByProduct := mapProductFiles(site, siteSettings, products, targetFiles, args.timeRange, precedence, args.exclusions.rules(siteSettings))
 
 	// 4. Write mapping to disk
-	writeResult(rangeSeriesToConfig, outputFileType, outputFileName)
+	policy := loadPolicy(siteSettings)
+	writeResult(products, filesByProduct, rangeSeriesToConfig, precedence, policy, rewriter, args.outputFileType, args.outputFileName)
+
+	// 5. Write explanations of the mapping, if requested
+	if args.explain {
+		writeExplanations(products, filesByProduct, precedence, policy, rewriter, args.outputFileName)
+	}
 
 }
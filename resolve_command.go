@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"log"
+	"os"
+	"strings"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/mapping"
+	"git.axiom/axiom/range-series-config-mapper/internal/product"
+	"git.axiom/axiom/range-series-config-mapper/internal/read"
+)
+
+const resolveCommand = "resolve"
+
+// Separates the path and config of each output record
+const resolveFieldSeparator = "\t"
+
+func runResolveCommand(args []string) {
+	flags := flag.NewFlagSet(resolveCommand, flag.ExitOnError)
+	siteDir := flags.String("site-dir", "", "Absolute path to HFR site directory.")
+	settingsFile := flags.String("settings", "", "Path to a JSON file with per-site settings.")
+	productNames := flags.String("products", product.RangeSeriesName, "Comma-separated list of the products whose files are resolved.")
+	nullDelimited := flags.Bool("null", false, "Input paths and output records are separated by NUL bytes instead of newlines.")
+	paths := addPathFlags(flags)
+	flags.Parse(args)
+
+	if *siteDir == "" {
+		log.Fatalln("Error: --site-dir must be specified.")
+	}
+
+	siteSettings := loadSettings(*settingsFile)
+	site := openSite(*siteDir)
+	precedence := loadPrecedence(site, siteSettings)
+	products := selectProducts(strings.Split(*productNames, ","), siteSettings)
+	policy := loadPolicy(siteSettings)
+	rewriter := paths.rewriter(site)
+
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	terminator := "\n"
+	if *nullDelimited {
+		scanner.Split(read.ScanNull)
+		terminator = "\x00"
+	}
+	output := bufio.NewWriter(os.Stdout)
+
+	// Every input path produces one record, with an empty config if it cannot be resolved, so that the output lines up
+	// with the input
+	for scanner.Scan() {
+		path := scanner.Text()
+		if !*nullDelimited {
+			path = strings.TrimRight(path, "\r")
+		}
+		if path == "" {
+			continue
+		}
+
+		var config string
+		prod, ok := product.ForFile(products, path)
+		if len(products) == 1 {
+			prod, ok = products[0], true
+		}
+
+		if !ok {
+			log.Printf("Warning: '%s' does not match any of the selected products\n", path)
+		} else if productTime, err := mapping.ParseProductTime(prod, path); err != nil {
+			log.Printf("Warning: %s file '%s': %v\n", prod.Name, path, err)
+		} else {
+			precedence.ExtendOpenIntervals()
+			resolution := precedence.Resolve(productTime, policy)
+			config = resolution.Config()
+
+			// Unapproved files cannot fail the stream, so they are left unresolved under the error policy
+			if resolution.Unapproved != "" {
+				log.Printf("Warning: %s file '%s' lies within operator window '%s' but resolves to auto config %v\n", prod.Name, path, resolution.Unapproved, config)
+				if policy.Unapproved == mapping.UnapprovedError {
+					config = ""
+				}
+			}
+		}
+		if config != "" {
+			config = rewriter.Output(config)
+		}
+
+		output.WriteString(path + resolveFieldSeparator + config + terminator)
+		if err := output.Flush(); err != nil {
+			log.Fatalf("Error writing output: %v", err)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		log.Fatalf("Error reading input: %v", err)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/product"
+	"git.axiom/axiom/range-series-config-mapper/internal/read"
+	"git.axiom/axiom/range-series-config-mapper/internal/server"
+	"git.axiom/axiom/range-series-config-mapper/internal/settings"
+	"git.axiom/axiom/range-series-config-mapper/internal/siteindex"
+)
+
+const serveCommand = "serve"
+
+// Separates a site directory from its settings file in `--site` values
+const siteSettingsSeparator = ","
+
+func newSiteLoader(siteDir string, siteSettings settings.Settings) server.Loader {
+	return func() (*siteindex.Index, error) {
+		site, err := read.OpenSite(siteDir)
+		if err != nil {
+			return nil, err
+		}
+
+		return siteindex.Load(site, siteSettings)
+	}
+}
+
+func runServeCommand(args []string) {
+	var sites repeatedFlag
+
+	flags := flag.NewFlagSet(serveCommand, flag.ExitOnError)
+	flags.Var(&sites, "site", "HFR site directory to serve, optionally followed by ',' and the path to its settings file. Can be repeated.")
+	addr := flags.String("addr", ":8080", "Address to listen on.")
+	rescanInterval := flags.Duration("rescan-interval", 5*time.Minute, "How often the sites' configs are rescanned. 0 disables rescanning.")
+	productNames := flags.String("products", product.RangeSeriesName, "Comma-separated list of the products whose files can be looked up by path.")
+	paths := addPathFlags(flags)
+	flags.Parse(args)
+
+	if len(sites) == 0 {
+		log.Fatalln("Error: At least one --site must be specified.")
+	}
+
+	loaders := make(map[string]server.Loader)
+	var customProducts []product.Product
+	for _, siteValue := range sites {
+		siteDir, settingsFile, _ := strings.Cut(siteValue, siteSettingsSeparator)
+		siteSettings := loadSettings(settingsFile)
+		customProducts = append(customProducts, siteSettings.Products...)
+
+		name := siteindex.SiteName(siteDir)
+		if _, ok := loaders[name]; ok {
+			log.Fatalf("Error: Site %v is specified more than once.", name)
+		}
+		loaders[name] = newSiteLoader(siteDir, siteSettings)
+	}
+
+	products, err := product.Select(strings.Split(*productNames, ","), customProducts)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	srv, err := server.New(loaders, products, server.Paths{Rules: paths.rules(), Relative: *paths.relativePaths})
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+
+	if *rescanInterval > 0 {
+		go srv.RescanEvery(*rescanInterval, nil)
+	}
+
+	log.Printf("Serving %d site(s) on %v\n", len(loaders), *addr)
+	log.Fatal(http.ListenAndServe(*addr, srv.Handler()))
+}
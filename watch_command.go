@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"git.axiom/axiom/range-series-config-mapper/internal/pathmap"
+	"git.axiom/axiom/range-series-config-mapper/internal/product"
+	"git.axiom/axiom/range-series-config-mapper/internal/watch"
+)
+
+const watchCommand = "watch"
+
+// openRecordOutput returns the writer NDJSON records are written to. Records are appended to an existing file.
+func openRecordOutput(outputFile string) io.WriteCloser {
+	if outputFile == "" {
+		return os.Stdout
+	}
+
+	file, err := os.OpenFile(outputFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		log.Fatalf("Error opening output file %v: %v", outputFile, err)
+	}
+
+	return file
+}
+
+// rewriteRecord rewrites the paths of a record for output
+func rewriteRecord(record *watch.Record, rewriter pathmap.Rewriter) {
+	for _, path := range []*string{&record.File, &record.Config, &record.PreviousConfig, &record.ClosedBy} {
+		if *path != "" {
+			*path = rewriter.Output(*path)
+		}
+	}
+}
+
+func runWatchCommand(args []string) {
+	flags := flag.NewFlagSet(watchCommand, flag.ExitOnError)
+	siteDir := flags.String("site-dir", "", "Absolute path to HFR site directory.")
+	settingsFile := flags.String("settings", "", "Path to a JSON file with per-site settings.")
+	productNames := flags.String("products", product.RangeSeriesName, "Comma-separated list of the products to watch.")
+	pollInterval := flags.Duration("poll-interval", time.Minute, "How often the site is polled for new files and configs.")
+	outputFile := flags.String("output-file", "", "File to append NDJSON records to. Defaults to stdout.")
+	emitExisting := flags.Bool("existing", false, "Also emit records for the files already present when watching starts.")
+	retention := flags.Duration("retention", 30*24*time.Hour, "How long before the newest file seen files are remembered and remapped when their config changes. 0 remembers all files.")
+	paths := addPathFlags(flags)
+	exclusions := addExclusionFlags(flags)
+	flags.Parse(args)
+
+	if *siteDir == "" {
+		log.Fatalln("Error: --site-dir must be specified.")
+	}
+	if *pollInterval <= 0 {
+		log.Fatalln("Error: --poll-interval must be positive.")
+	}
+	if *retention < 0 {
+		log.Fatalln("Error: --retention must not be negative.")
+	}
+
+	siteSettings := loadSettings(*settingsFile)
+	site := openSite(*siteDir)
+	products := selectProducts(strings.Split(*productNames, ","), siteSettings)
+
+	output := openRecordOutput(*outputFile)
+	defer output.Close()
+	encoder := json.NewEncoder(output)
+
+	rewriter := paths.rewriter(site)
+	watcher := watch.New(site, siteSettings, products, exclusions.rules(siteSettings), *retention, *emitExisting)
+	log.Printf("Watching %v every %v\n", site.Root, *pollInterval)
+
+	ticker := time.NewTicker(*pollInterval)
+	defer ticker.Stop()
+
+	for {
+		records, err := watcher.Poll()
+		if err != nil {
+			// The site may be temporarily unavailable, e.g. while a network mount reconnects
+			log.Printf("Error polling site, retrying at the next poll: %v\n", err)
+		}
+
+		for _, record := range records {
+			rewriteRecord(&record, rewriter)
+			if err := encoder.Encode(record); err != nil {
+				log.Fatalf("Error writing record: %v", err)
+			}
+		}
+
+		<-ticker.C
+	}
+}